@@ -1,12 +1,16 @@
 package benchmark
 
 import (
+	"bufio"
 	"errors"
+	"fmt"
+	"io"
 	"runtime"
 	"testing"
 
 	"github.com/jjunac/betterr"
 	"github.com/joomcode/errorx"
+	pkgerrors "github.com/pkg/errors"
 	"github.com/rotisserie/eris"
 	"github.com/stretchr/testify/assert"
 )
@@ -41,6 +45,12 @@ var ErrorFrameworks = []struct {
 			return errorx.IllegalState.New("An Errorx error")
 		},
 	},
+	{
+		Name: "PkgErrors",
+		Func: func() error {
+			return pkgerrors.New("A pkg/errors error")
+		},
+	},
 	{
 		Name: "Errors",
 		Func: func() error {
@@ -82,3 +92,145 @@ func TestRecursiveError(t *testing.T) {
 	nb_frame_test := runtime.Callers(0, make([]uintptr, 64))
 	assert.Equal(t, 10, betterror.Stack.FramesLen()-nb_frame_test)
 }
+
+var Formatters = []struct {
+	Name      string
+	Formatter betterr.ErrorFormatter
+}{
+	{Name: "GoStyle", Formatter: &betterr.GoStyleFormatter{}},
+	{Name: "JavaStyle", Formatter: &betterr.JavaStyleFormatter{}},
+	{Name: "Json", Formatter: &betterr.JsonFormatter{}},
+}
+
+func decoratedError(depth int) error {
+	err := recursiveError(20, func() error {
+		return betterr.New("A BetterError error")
+	})
+	for i := 0; i < depth; i++ {
+		err = recursiveError(20, func() error {
+			return betterr.Decoratef(err, "Decoration %d", i)
+		})
+	}
+	return err
+}
+
+// Compares building the whole string with Format and streaming it with FormatTo.
+// Streaming only saves the final string: most allocations come from resolving the frames, which both do.
+func Benchmark_Format(b *testing.B) {
+	err := decoratedError(50)
+	for _, f := range Formatters {
+		b.Run(f.Name+"/Format", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, _ = io.WriteString(io.Discard, f.Formatter.Format(err))
+			}
+		})
+		b.Run(f.Name+"/FormatTo", func(b *testing.B) {
+			b.ReportAllocs()
+			w := bufio.NewWriter(io.Discard)
+			for i := 0; i < b.N; i++ {
+				_ = betterr.FormatTo(w, f.Formatter, err)
+				_ = w.Flush()
+			}
+		})
+	}
+}
+
+var errPlain = errors.New("A plain Go error")
+
+// Functions of the libraries adding a stack trace to an existing error, with or without a message.
+var WrapFrameworks = []struct {
+	Name     string
+	Wrap     func(err error) error
+	Decorate func(err error) error
+}{
+	{
+		Name:     "Betterr",
+		Wrap:     betterr.Wrap,
+		Decorate: func(err error) error { return betterr.Decorate(err, "Decoration") },
+	},
+	{
+		Name:     "Eris",
+		Wrap:     func(err error) error { return eris.Wrap(err, "") },
+		Decorate: func(err error) error { return eris.Wrap(err, "Decoration") },
+	},
+	{
+		Name:     "Errorx",
+		Wrap:     func(err error) error { return errorx.EnsureStackTrace(err) },
+		Decorate: func(err error) error { return errorx.Decorate(err, "Decoration") },
+	},
+	{
+		Name:     "PkgErrors",
+		Wrap:     pkgerrors.WithStack,
+		Decorate: func(err error) error { return pkgerrors.Wrap(err, "Decoration") },
+	},
+	{
+		Name:     "Errors",
+		Wrap:     func(err error) error { return fmt.Errorf("%w", err) },
+		Decorate: func(err error) error { return fmt.Errorf("Decoration: %w", err) },
+	},
+}
+
+// The following benchmarks track the allocations of the creation of errors, BettErr takes a single one per error.
+
+func Benchmark_New(b *testing.B) {
+	for _, ef := range ErrorFrameworks {
+		b.Run(ef.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = ef.Func()
+			}
+		})
+	}
+}
+
+func Benchmark_Wrap(b *testing.B) {
+	for _, wf := range WrapFrameworks {
+		b.Run(wf.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = wf.Wrap(errPlain)
+			}
+		})
+	}
+}
+
+func Benchmark_Decorate(b *testing.B) {
+	for _, wf := range WrapFrameworks {
+		b.Run(wf.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = wf.Decorate(errPlain)
+			}
+		})
+	}
+}
+
+// Compares formatting a chain of 10 decorations with its stack traces, the way each library prints them
+func Benchmark_FormatLibraries(b *testing.B) {
+	libraries := []struct {
+		Name   string
+		Err    error
+		Format func(err error) string
+	}{
+		{Name: "Betterr", Err: decorate(betterr.New("A BetterError error"), WrapFrameworks[0].Decorate), Format: new(betterr.JavaStyleFormatter).Format},
+		{Name: "Eris", Err: decorate(eris.New("An Eris error"), WrapFrameworks[1].Decorate), Format: func(err error) string { return eris.ToString(err, true) }},
+		{Name: "Errorx", Err: decorate(errorx.IllegalState.New("An Errorx error"), WrapFrameworks[2].Decorate), Format: func(err error) string { return fmt.Sprintf("%+v", err) }},
+		{Name: "PkgErrors", Err: decorate(pkgerrors.New("A pkg/errors error"), WrapFrameworks[3].Decorate), Format: func(err error) string { return fmt.Sprintf("%+v", err) }},
+	}
+	for _, l := range libraries {
+		b.Run(l.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, _ = io.WriteString(io.Discard, l.Format(l.Err))
+			}
+		})
+	}
+}
+
+func decorate(err error, decorate func(err error) error) error {
+	for i := 0; i < 10; i++ {
+		err = decorate(err)
+	}
+	return err
+}
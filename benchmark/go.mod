@@ -5,6 +5,7 @@ go 1.23.1
 require (
 	github.com/jjunac/betterr v0.0.0
 	github.com/joomcode/errorx v1.2.0
+	github.com/pkg/errors v0.9.1
 	github.com/rotisserie/eris v0.5.4
 	github.com/stretchr/testify v1.10.0
 )
@@ -16,4 +17,3 @@ require (
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
-
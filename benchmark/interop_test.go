@@ -0,0 +1,63 @@
+package benchmark
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jjunac/betterr"
+	"github.com/joomcode/errorx"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/rotisserie/eris"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPkgError() error {
+	return pkgerrors.Wrap(pkgerrors.New("connection refused"), "query failed")
+}
+
+func newErisError() error {
+	return eris.Wrap(eris.New("connection refused"), "query failed")
+}
+
+func newErrorxError() error {
+	return errorx.Decorate(errorx.IllegalState.New("connection refused"), "query failed")
+}
+
+// The stacks of the errors of the other libraries are found by the default stack extractors of betterr
+func TestStackOf_OtherLibraries(t *testing.T) {
+	testCases := []struct {
+		name     string
+		newError func() error
+		origin   string
+	}{
+		{"pkg/errors", newPkgError, "github.com/jjunac/betterr/benchmark.newPkgError"},
+		{"eris", newErisError, "github.com/jjunac/betterr/benchmark.newErisError"},
+		{"errorx", newErrorxError, "github.com/jjunac/betterr/benchmark.newErrorxError"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := betterr.Decorate(tc.newError(), "cannot load user")
+
+			stack := betterr.StackOf(err)
+			if assert.NotNil(t, stack) {
+				assert.Equal(t, tc.origin, stack.GetFrames()[0].Function)
+			}
+			assert.Contains(t, new(betterr.JavaStyleFormatter).Format(err), "\n    at "+tc.origin+" (")
+		})
+	}
+}
+
+// The stack trace of BetterErrors is structurally compatible with the one of pkg/errors, and formatted the same way
+func TestStackTrace_PkgErrorsCompatibility(t *testing.T) {
+	trace := betterr.New("failed").(*betterr.BetterError).StackTrace()
+	converted := make(pkgerrors.StackTrace, len(trace))
+	for i, frame := range trace {
+		converted[i] = pkgerrors.Frame(frame)
+	}
+	for _, format := range []string{"%s", "%v", "%+v"} {
+		assert.Equal(t, fmt.Sprintf(format, converted), fmt.Sprintf(format, trace), format)
+	}
+	for _, format := range []string{"%s", "%d", "%n", "%v", "%+s", "%+v"} {
+		assert.Equal(t, fmt.Sprintf(format, converted[0]), fmt.Sprintf(format, trace[0]), format)
+	}
+}
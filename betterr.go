@@ -6,9 +6,25 @@ import (
 )
 
 type BetterError struct {
-	Msg     string
-	Wrapped error
-	Stack   Stacktrace
+	// Msg is the message of the error. It is empty when the message is formatted from Template, use [BetterError.Message] to read it.
+	Msg      string
+	// Template and Args are the format string and arguments of [Errorf] and [Decoratef].
+	// The message is only formatted when it is needed, so the arguments are retained by the error
+	// and must not be modified after its creation.
+	Template string
+	Args     []any
+	Wrapped  error
+	Stack    Stacktrace
+	Attrs    []Attr
+	// Kind is the [ErrorKind] the error was created from, and Details the details it was created with, see [Kind].
+	Kind     error
+	Details  any
+}
+
+// Attr is a key-value pair attached to a BetterError to provide structured context.
+type Attr struct {
+	Key   string
+	Value any
 }
 
 var GetStacktrace func(skip int)Stacktrace = NewRuntimeStacktrace
@@ -16,20 +32,23 @@ var GetStacktrace func(skip int)Stacktrace = NewRuntimeStacktrace
 // Creates a new BetterError with the provided message.
 // The stack trace will start from the caller of this function.
 // This would be the equivalent of Go's errors.New(msg) or Java's new Exception(msg).
+// The error and its stack trace take a single allocation, the frames are only resolved when the error is formatted.
 func New(msg string) error {
-	return &BetterError{
-		Msg:   msg,
-		Stack: GetStacktrace(1),
-	}
+	err := newBetterError(1)
+	err.Msg = msg
+	return err
 }
 
 // Creates a new BetterError with the provided formatted message.
+// The format string and the arguments are kept in the error, and the message is only formatted when it is needed.
+// Like fmt.Errorf, the errors of the %w verbs are wrapped, several %w verbs wrapping all their errors (see errors.Join).
+// They are printed as the causes of the error rather than in its message, e.g. Errorf("cannot load %s: %w", name, err)
+// gives the message "cannot load name" and the cause err.
 // See [New] for more information.
 func Errorf(format string, args ...any) error {
-	return &BetterError{
-		Msg:   fmt.Sprintf(format, args...),
-		Stack: GetStacktrace(1),
-	}
+	err := newBetterError(1)
+	setFormatted(err, nil, format, args)
+	return err
 }
 
 // Wraps the error in a BetterError.
@@ -44,10 +63,9 @@ func Wrap(err error) error {
 	if betterr, ok := err.(*BetterError); ok {
 		return betterr
 	} else {
-		return &BetterError{
-			Msg:   err.Error(),
-			Stack: GetStacktrace(1),
-		}
+		betterr = newBetterError(1)
+		betterr.Msg = err.Error()
+		return betterr
 	}
 }
 
@@ -59,24 +77,102 @@ func Decorate(err error, msg string) error {
 	if err == nil {
 		return nil
 	}
-	return &BetterError{
-		Msg:   msg,
-		Wrapped: err,
-		Stack: GetStacktrace(1),
-	}
+	betterr := newBetterError(1)
+	betterr.Msg = msg
+	betterr.Wrapped = err
+	return betterr
 }
 
 // Decorates the error in a BetterError and adds a formatted message.
+// Like [Errorf], the message is only formatted when it is needed, and the errors of the %w verbs are wrapped along with err.
 // See [Decorate] for more information.
 func Decoratef(err error, format string, args ...any) error {
 	if err == nil {
 		return nil
 	}
-	return &BetterError{
-		Msg:   fmt.Sprintf(format, args...),
-		Wrapped: err,
-		Stack: GetStacktrace(1),
+	betterr := newBetterError(1)
+	setFormatted(betterr, err, format, args)
+	return betterr
+}
+
+// Same as [New], but the stack trace starts skip frames above the caller of this function, like [GetStacktrace].
+// Intended for the helpers creating errors on behalf of their caller, which pass 1 so the stack starts at their caller.
+func NewSkip(skip int, msg string) error {
+	err := newBetterError(skip + 1)
+	err.Msg = msg
+	return err
+}
+
+// Same as [Errorf], but the stack trace starts skip frames above the caller of this function, see [NewSkip].
+func ErrorfSkip(skip int, format string, args ...any) error {
+	err := newBetterError(skip + 1)
+	setFormatted(err, nil, format, args)
+	return err
+}
+
+// Same as [Decorate], but the stack trace starts skip frames above the caller of this function, see [NewSkip].
+func DecorateSkip(skip int, err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+	betterr := newBetterError(skip + 1)
+	betterr.Msg = msg
+	betterr.Wrapped = err
+	return betterr
+}
+
+// Same as [Decoratef], but the stack trace starts skip frames above the caller of this function, see [NewSkip].
+func DecoratefSkip(skip int, err error, format string, args ...any) error {
+	if err == nil {
+		return nil
+	}
+	betterr := newBetterError(skip + 1)
+	setFormatted(betterr, err, format, args)
+	return betterr
+}
+
+// Returns the message of the error, without the message of its causes.
+// The message is formatted from Template and Args when they are set, otherwise it is Msg.
+func (e *BetterError) Message() string {
+	if e.Template != "" {
+		return fmt.Sprintf(e.Template, e.Args...)
+	}
+	return e.Msg
+}
+
+// Key of the attribute naming the service an error was received from, for errors propagated between services.
+// [JavaStyleFormatter] shows it in the "Caused by" line, e.g. "Caused by (remote service users): not found".
+const RemoteServiceKey = "remote_service"
+
+// Returns the name of the service the error was received from, or an empty string for local errors.
+func (e *BetterError) remoteService() string {
+	for _, attr := range e.Attrs {
+		if service, ok := attr.Value.(string); ok && attr.Key == RemoteServiceKey {
+			return service
+		}
+	}
+	return ""
+}
+
+// Attaches the attributes to the error.
+// If the error is not a BetterError, it is wrapped first (see [Wrap]).
+// The original error is left untouched, a copy holding the attributes is returned.
+// Attaching attributes to a nil error will return nil.
+func WithAttrs(err error, attrs ...Attr) error {
+	if err == nil {
+		return nil
+	}
+	betterr, ok := err.(*BetterError)
+	if !ok {
+		// The new error is not shared yet, no need to copy it
+		betterr = newBetterError(1)
+		betterr.Msg = err.Error()
+		betterr.Attrs = append([]Attr(nil), attrs...)
+		return betterr
 	}
+	cp := *betterr
+	cp.Attrs = append(cp.Attrs[:len(cp.Attrs):len(cp.Attrs)], attrs...)
+	return &cp
 }
 
 func Is(err, target error) bool {
@@ -95,20 +191,24 @@ func (e *BetterError) Format(errFmt ErrorFormatter) string {
 }
 
 // Is reports whether any error in err's tree matches target, as defined by the [errors.Is] interface.
-// For now, it checks if the target error message is equal to one of the error messages in the tree.
+// An [ErrorKind] target matches the errors of this kind only. For other targets, for now,
+// it checks if the target error message is equal to one of the error messages in the tree.
 func (e *BetterError) Is(target error) bool {
+	if _, isKind := target.(errorKind); isKind {
+		return e.Kind == target
+	}
 	if e == target {
 		return true
 	}
 	if betterrTarget, ok := target.(*BetterError); ok {
-		if e.Msg == betterrTarget.Msg {
+		if e.Message() == betterrTarget.Message() {
 			return true
 		}
 		if e.Wrapped != nil {
 			return Is(e.Wrapped, target)
 		}
 	}
-	if e.Msg == target.Error() {
+	if e.Message() == target.Error() {
 		return true
 	}
 	if e.Wrapped != nil {
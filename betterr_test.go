@@ -6,95 +6,6 @@ import (
 	"testing"
 )
 
-func TestErrorFormatter(t *testing.T) {
-	testCases := []struct {
-		err               error
-		expectedRegexGo   string
-		expectedRegexJava string
-		expectedRegexJson map[string]any
-	}{
-		{
-			err:               errors.New("A plain Go error"),
-			expectedRegexGo:   "A plain Go error",
-			expectedRegexJava: "A plain Go error",
-			expectedRegexJson: map[string]any{
-				"message": "A plain Go error",
-			},
-		},
-		{
-			err:             New("A BetterError error"),
-			expectedRegexGo: "A BetterError error",
-			expectedRegexJava: "A BetterError error\n" +
-				"    at github\\.com/jjunac/betterr\\.TestErrorFormatter \\(.*/betterr_test.go:\\d+\\)\n" +
-				"    at testing\\.tRunner \\(.*/go/src/testing/testing.go:\\d+\\)\n" +
-				"    at runtime\\.goexit \\(.*\\)\n",
-		},
-		{
-			err:             Wrap(errors.New("A wrapped plain Go error")),
-			expectedRegexGo: "A wrapped plain Go error",
-			expectedRegexJava: "A wrapped plain Go error\n" +
-				"    at github\\.com/jjunac/betterr\\.TestErrorFormatter \\(.*/betterr_test.go:\\d+\\)\n" +
-				"    at testing\\.tRunner \\(.*/go/src/testing/testing.go:\\d+\\)\n" +
-				"    at runtime\\.goexit \\(.*\\)\n",
-		},
-		{
-			err:             Wrap(New("A wrapped BetterError error")),
-			expectedRegexGo: "A wrapped BetterError error",
-			expectedRegexJava: "A wrapped BetterError error\n" +
-				"    at github\\.com/jjunac/betterr\\.TestErrorFormatter \\(.*/betterr_test.go:\\d+\\)\n" +
-				"    at testing\\.tRunner \\(.*/go/src/testing/testing.go:\\d+\\)\n" +
-				"    at runtime\\.goexit \\(.*\\)\n",
-		},
-		{
-			err:             Decorate(errors.New("A plain Go error"), "Decorated"),
-			expectedRegexGo: "Decorated: A plain Go error",
-			expectedRegexJava: "Decorated\n" +
-				"    at github\\.com/jjunac/betterr\\.TestErrorFormatter \\(.*/betterr_test.go:\\d+\\)\n" +
-				"    at testing\\.tRunner \\(.*/go/src/testing/testing.go:\\d+\\)\\\n" +
-				"    at runtime\\.goexit \\(.*\\)\n" +
-				"Caused by: A plain Go error",
-		},
-		{
-			err:             Decorate(New("A BetterError error"), "Decorated"),
-			expectedRegexGo: "Decorated: A BetterError error",
-			expectedRegexJava: "Decorated\n" +
-				"    at github\\.com/jjunac/betterr\\.TestErrorFormatter \\(.*/betterr_test.go:\\d+\\)\n" +
-				"    at testing\\.tRunner \\(.*/go/src/testing/testing.go:\\d+\\)\\\n" +
-				"    at runtime\\.goexit \\(.*\\)\n" +
-				"Caused by: A BetterError error\n" +
-				"    at github\\.com/jjunac/betterr\\.TestErrorFormatter \\(.*/betterr_test.go:\\d+\\)\n" +
-				"    at testing\\.tRunner \\(.*/go/src/testing/testing.go:\\d+\\)\n" +
-				"    at runtime\\.goexit \\(.*\\)\n",
-		},
-		{
-			err:             Decoratef(Decorate(errors.New("A plain Go error"), "Decorated"), "A %s level of decoration", "second"),
-			expectedRegexGo: "A second level of decoration: Decorated: A plain Go error",
-			expectedRegexJava: "A second level of decoration\n" +
-				"    at github\\.com/jjunac/betterr\\.TestErrorFormatter \\(.*/betterr_test.go:\\d+\\)\n" +
-				"    at testing\\.tRunner \\(.*/go/src/testing/testing.go:\\d+\\)\\\n" +
-				"    at runtime\\.goexit \\(.*\\)\n" +
-				"Caused by: Decorated\n" +
-				"    at github\\.com/jjunac/betterr\\.TestErrorFormatter \\(.*/betterr_test.go:\\d+\\)\n" +
-				"    at testing\\.tRunner \\(.*/go/src/testing/testing.go:\\d+\\)\\\n" +
-				"    at runtime\\.goexit \\(.*\\)\n" +
-				"Caused by: A plain Go error",
-		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.expectedRegexGo, func(t *testing.T) {
-			assertRegexp(t, tc.expectedRegexGo, new(GoStyleFormatter).Format(tc.err))
-			assertRegexp(t, tc.expectedRegexJava, new(JavaStyleFormatter).Format(tc.err))
-			// Testing JSON is shitty so we don't do it every time
-			if tc.expectedRegexJson != nil {
-				expectedJson, err := json.Marshal(tc.expectedRegexJson)
-				assertNoError(t, err)
-				assertJSONEq(t, string(expectedJson), new(JsonFormatter).Format(tc.err))
-			}
-		})
-	}
-}
-
 type mockedStacktrace struct {
 	frames []StackFrames
 }
@@ -158,7 +69,8 @@ func TestErrorFormatter_MockedStacktrace(t *testing.T) {
 		new(JavaStyleFormatter).Format(decoratedErr))
 
 	expectedJson := map[string]any{
-		"message": "process failed",
+		"fingerprint": Fingerprint(decoratedErr),
+		"message":     "process failed",
 		"stack": []map[string]any{
 			{
 				"function": "github.com/myapp.MyFunction",
@@ -257,3 +169,152 @@ func TestErrorf(t *testing.T) {
 	assertTrue(t, Is(err, New("A formatted error message: 1234")))
 	assertEqual(t, "A formatted error message: 1234", new(GoStyleFormatter).Format(err))
 }
+
+func TestWithAttrs(t *testing.T) {
+	assertTrue(t, WithAttrs(nil, Attr{Key: "id", Value: 1}) == nil)
+
+	base := New("not found")
+	err := WithAttrs(base, Attr{Key: "id", Value: 1}, Attr{Key: "table", Value: "users"})
+	assertEqual(t, 0, len(base.(*BetterError).Attrs))
+	assertEqual(t, 2, len(err.(*BetterError).Attrs))
+	assertTrue(t, Is(err, base))
+
+	wrapped := WithAttrs(errors.New("A plain Go error"), Attr{Key: "id", Value: 1})
+	assertEqual(t, "A plain Go error", wrapped.(*BetterError).Msg)
+	assertEqual(t, "github.com/jjunac/betterr.TestWithAttrs", wrapped.(*BetterError).Stack.GetFrames()[0].Function)
+
+	mocked := &BetterError{Msg: "A plain Go error", Stack: &mockedStacktrace{}, Attrs: wrapped.(*BetterError).Attrs}
+	assertJSONEq(t,
+		`{"fingerprint": "`+Fingerprint(mocked)+`", "message": "A plain Go error", "attributes": {"id": 1}}`,
+		new(JsonFormatter).Format(mocked))
+}
+
+func TestJavaStyleFormatter_RemoteService(t *testing.T) {
+	remote := &BetterError{
+		Msg:   "user not found",
+		Stack: &StaticStacktrace{Frames: []StackFrames{{Function: "github.com/users.Get", File: "users.go", Line: 12}}},
+		Attrs: []Attr{{Key: RemoteServiceKey, Value: "users"}},
+	}
+	assertEqual(t,
+		"(remote service users): user not found\n"+
+			"    at github.com/users.Get (users.go:12)\n",
+		new(JavaStyleFormatter).Format(remote))
+	assertEqual(t,
+		"cannot login\n"+
+			"Caused by (remote service users): user not found\n"+
+			"    at github.com/users.Get (users.go:12)\n",
+		new(JavaStyleFormatter).Format(&BetterError{Msg: "cannot login", Stack: &StaticStacktrace{}, Wrapped: remote}))
+}
+
+type countingStringer struct {
+	calls *int
+}
+
+func (s countingStringer) String() string {
+	*s.calls++
+	return "value"
+}
+
+func TestErrorf_KeepsTemplateAndArgs(t *testing.T) {
+	calls := 0
+	err := Decoratef(errors.New("cause"), "cannot load %s (%d)", countingStringer{&calls}, 42).(*BetterError)
+	assertEqual(t, "cannot load %s (%d)", err.Template)
+	assertEqual(t, 2, len(err.Args))
+	assertEqual(t, "", err.Msg)
+	// The message is not formatted until it is needed
+	assertEqual(t, 0, calls)
+	assertEqual(t, "cannot load value (42)", err.Message())
+	assertEqual(t, "cannot load value (42): cause", new(GoStyleFormatter).Format(err))
+	assertTrue(t, Is(err, Errorf("cannot load %s (%d)", "value", 42)))
+}
+
+func TestJsonFormatter_TemplateAndArgs(t *testing.T) {
+	err := &BetterError{Template: "user %s not found (%d)", Args: []any{"bob", 42}, Stack: &mockedStacktrace{}}
+	assertJSONEq(t,
+		`{"fingerprint": "`+Fingerprint(err)+`", "message": "user bob not found (42)", "template": "user %s not found (%d)", "args": ["bob", 42]}`,
+		new(JsonFormatter).Format(err))
+}
+
+type allocDetails struct {
+	Resource string
+	ID       int
+}
+
+func TestConstructors_SingleAllocation(t *testing.T) {
+	plainErr := errors.New("plain")
+	kind := Kind[int]("kind")
+	structKind := Kind[allocDetails]("struct kind")
+	pointerKind := Kind[*allocDetails]("pointer kind")
+	details := &allocDetails{Resource: "user", ID: 42}
+	testCases := map[string]struct {
+		fn     func()
+		allocs float64
+	}{
+		"New":           {func() { _ = New("failed") }, 1},
+		"Wrap":          {func() { _ = Wrap(plainErr) }, 1},
+		"Decorate":      {func() { _ = Decorate(plainErr, "failed") }, 1},
+		"WithAttrs":     {func() { _ = WithAttrs(plainErr) }, 1},
+		"Kind.New":      {func() { _ = kind.New(0) }, 1},
+		"Kind.Decorate": {func() { _ = kind.Decorate(plainErr, 0) }, 1},
+		// Storing the details in an interface allocates, unless they are pointers
+		"Kind.New/struct":      {func() { _ = structKind.New(*details) }, 2},
+		"Kind.Decorate/struct": {func() { _ = structKind.Decorate(plainErr, *details) }, 2},
+		"Kind.New/pointer":     {func() { _ = pointerKind.New(details) }, 1},
+		"GetStacktrace":        {func() { _ = GetStacktrace(0) }, 1},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assertEqual(t, tc.allocs, testing.AllocsPerRun(100, tc.fn))
+		})
+	}
+}
+
+func TestConstructors_ReplacedGetStacktrace(t *testing.T) {
+	stack := &StaticStacktrace{Frames: []StackFrames{{Function: "main.main", File: "main.go", Line: 1}}}
+	GetStacktrace = func(skip int) Stacktrace {
+		return stack
+	}
+	defer func() {
+		GetStacktrace = NewRuntimeStacktrace
+	}()
+	assertTrue(t, New("failed").(*BetterError).Stack == stack)
+	assertTrue(t, Errorf("failed %d", 1).(*BetterError).Stack == stack)
+}
+
+func TestConstructors_StackStartsAtCaller(t *testing.T) {
+	errs := map[string]error{
+		"New":       New("failed"),
+		"Errorf":    Errorf("failed: %w", errors.New("plain")),
+		"Wrap":      Wrap(errors.New("plain")),
+		"Decorate":  Decorate(errors.New("plain"), "failed"),
+		"Decoratef": Decoratef(errors.New("plain"), "failed %d", 1),
+		"WithAttrs": WithAttrs(errors.New("plain")),
+	}
+	for name, err := range errs {
+		t.Run(name, func(t *testing.T) {
+			assertEqual(t, "github.com/jjunac/betterr.TestConstructors_StackStartsAtCaller", err.(*BetterError).Stack.GetFrames()[0].Function)
+			assertEqual(t, err.(*BetterError).Stack.FramesLen(), len(err.(*BetterError).Callers()))
+		})
+	}
+}
+
+// Creates the errors on behalf of its caller, like the helpers of other packages do.
+func newErrorsOnBehalf() map[string]error {
+	return map[string]error{
+		"NewSkip":       NewSkip(1, "failed"),
+		"ErrorfSkip":    ErrorfSkip(1, "failed: %w", errors.New("plain")),
+		"DecorateSkip":  DecorateSkip(1, errors.New("plain"), "failed"),
+		"DecoratefSkip": DecoratefSkip(1, errors.New("plain"), "failed %d", 1),
+	}
+}
+
+func TestSkipConstructors(t *testing.T) {
+	for name, err := range newErrorsOnBehalf() {
+		t.Run(name, func(t *testing.T) {
+			assertEqual(t, "github.com/jjunac/betterr.TestSkipConstructors", err.(*BetterError).Stack.GetFrames()[0].Function)
+		})
+	}
+	assertEqual(t, "failed: plain", new(GoStyleFormatter).Format(ErrorfSkip(0, "failed: %w", errors.New("plain"))))
+	assertTrue(t, DecorateSkip(0, nil, "failed") == nil)
+	assertTrue(t, DecoratefSkip(0, nil, "failed %d", 1) == nil)
+}
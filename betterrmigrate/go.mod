@@ -0,0 +1,6 @@
+module github.com/jjunac/betterr/betterrmigrate
+
+// golang.org/x/tools requires Go 1.25, its older versions don't build with the recent Go releases
+go 1.25.0
+
+require golang.org/x/tools v0.44.0
@@ -0,0 +1,116 @@
+// Command betterrmigrate rewrites the code using github.com/pkg/errors, github.com/rotisserie/eris
+// or github.com/joomcode/errorx to use BettErr instead.
+//
+// Usage:
+//   betterrmigrate [-w] [-l] [paths...]
+//
+// The paths are Go files or directories, walked recursively (e.g. "." or "./..."), the current directory by default.
+// Like gofmt, the rewritten files are printed unless -w is set, and -l only lists the files to rewrite.
+// The calls of the libraries are rewritten into their equivalent, e.g. errors.Wrap(err, msg) into betterr.Decorate(err, msg),
+// and the imports are fixed. The other uses of the libraries, e.g. the errorx error types, are reported to be migrated by hand.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// Runs the command line and returns the exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("betterrmigrate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	write := flags.Bool("w", false, "write the result to the files instead of printing it")
+	list := flags.Bool("l", false, "list the files to rewrite")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	exitCode := 0
+	for _, root := range paths {
+		// Directories are walked recursively anyway, "./..." is accepted like in the go command
+		root = filepath.Clean(strings.TrimSuffix(root, "..."))
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				name := entry.Name()
+				if path != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(path, ".go") {
+				return nil
+			}
+			return migrateFile(path, *write, *list, stdout, stderr)
+		})
+		if err != nil {
+			fmt.Fprintln(stderr, "betterrmigrate:", err)
+			exitCode = 1
+		}
+	}
+	return exitCode
+}
+
+func migrateFile(path string, write, list bool, stdout, stderr io.Writer) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	output, changed, err := migrate(path, src, stderr)
+	if err != nil || !changed {
+		return err
+	}
+	switch {
+	case list:
+		fmt.Fprintln(stdout, path)
+	case write:
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, output, info.Mode().Perm())
+	default:
+		_, err = stdout.Write(output)
+	}
+	return err
+}
+
+// Returns the migrated source of the file, and whether it changed. The uses that cannot be migrated are reported to stderr.
+func migrate(path string, src []byte, stderr io.Writer) ([]byte, bool, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, false, err
+	}
+	changed, warnings := rewriteFile(fset, file)
+	for _, w := range warnings {
+		fmt.Fprintln(stderr, w)
+	}
+	if !changed {
+		return src, false, nil
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, false, err
+	}
+	return buf.Bytes(), true, nil
+}
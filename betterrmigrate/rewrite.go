@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path"
+	"strconv"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+const betterrPath = "github.com/jjunac/betterr"
+
+// Rewrite of a function of another error library into a function of betterr.
+type rule struct {
+	function string
+	// Function used instead when the call has more arguments than the message, e.g. errorx.Decorate(err, "id %d", id)
+	formatted string
+	// Number of arguments of the call up to the message
+	args int
+}
+
+// Rules of the migrated libraries, by import path and function name.
+var rules = map[string]map[string]rule{
+	"github.com/pkg/errors": {
+		"New":          {function: "New"},
+		"Errorf":       {function: "Errorf"},
+		"Wrap":         {function: "Decorate"},
+		"Wrapf":        {function: "Decoratef"},
+		"WithStack":    {function: "Wrap"},
+		"WithMessage":  {function: "Decorate"},
+		"WithMessagef": {function: "Decoratef"},
+		"Cause":        {function: "Cause"},
+		"Is":           {function: "Is"},
+		"As":           {function: "As"},
+		"Unwrap":       {function: "Unwrap"},
+	},
+	"github.com/rotisserie/eris": {
+		"New":    {function: "New"},
+		"Errorf": {function: "Errorf"},
+		"Wrap":   {function: "Decorate"},
+		"Wrapf":  {function: "Decoratef"},
+		"Cause":  {function: "Cause"},
+		"Is":     {function: "Is"},
+		"As":     {function: "As"},
+		"Unwrap": {function: "Unwrap"},
+	},
+	"github.com/joomcode/errorx": {
+		"Decorate":          {function: "Decorate", formatted: "Decoratef", args: 2},
+		"EnhanceStackTrace": {function: "Decorate", formatted: "Decoratef", args: 2},
+	},
+}
+
+// Use of a migrated library that cannot be rewritten automatically.
+type warning struct {
+	pos token.Position
+	msg string
+}
+
+func (w warning) String() string {
+	return fmt.Sprintf("%s: %s", w.pos, w.msg)
+}
+
+// Rewrites the calls of the migrated libraries into calls of betterr and fixes the imports of the file.
+// The imports of the libraries are kept when they still have uses that cannot be rewritten, which are returned as warnings.
+// Returns whether the file changed.
+func rewriteFile(fset *token.FileSet, file *ast.File) (bool, []warning) {
+	var warnings []warning
+	changed := false
+	betterrName := importName(file, betterrPath)
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		libraryRules, ok := rules[importPath]
+		if !ok {
+			continue
+		}
+		name := path.Base(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if name == "_" || name == "." {
+			warnings = append(warnings, warning{fset.Position(spec.Pos()), fmt.Sprintf("cannot migrate the %q import of %s", name, importPath)})
+			continue
+		}
+
+		rewritten := map[*ast.SelectorExpr]bool{}
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			selector, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || !isPackage(selector.X, name) {
+				return true
+			}
+			r, ok := libraryRules[selector.Sel.Name]
+			if !ok {
+				return true
+			}
+			function := r.function
+			if r.formatted != "" && len(call.Args) > r.args {
+				function = r.formatted
+			}
+			selector.X.(*ast.Ident).Name = betterrName
+			selector.Sel.Name = function
+			rewritten[selector] = true
+			changed = true
+			return true
+		})
+		ast.Inspect(file, func(n ast.Node) bool {
+			if selector, ok := n.(*ast.SelectorExpr); ok && !rewritten[selector] && isPackage(selector.X, name) {
+				warnings = append(warnings, warning{fset.Position(selector.Pos()), fmt.Sprintf("cannot migrate %s.%s", name, selector.Sel.Name)})
+			}
+			return true
+		})
+	}
+	if !changed {
+		return false, warnings
+	}
+
+	imported := hasImport(file, betterrPath)
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		if _, ok := rules[importPath]; !ok || astutil.UsesImport(file, importPath) || (spec.Name != nil && (spec.Name.Name == "_" || spec.Name.Name == ".")) {
+			continue
+		}
+		if !imported {
+			// The import of betterr takes the place of the import of the library, keeping the groups of the imports
+			spec.Path.Value = strconv.Quote(betterrPath)
+			spec.Name = nil
+			imported = true
+			continue
+		}
+		name := ""
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		astutil.DeleteNamedImport(fset, file, name, importPath)
+	}
+	if !imported {
+		astutil.AddImport(fset, file, betterrPath)
+	}
+	ast.SortImports(fset, file)
+	return true, warnings
+}
+
+// Returns whether the expression is the name of an imported package.
+// The identifiers declared in the file, e.g. a variable shadowing the package, are resolved by the parser.
+func isPackage(expr ast.Expr, name string) bool {
+	id, ok := expr.(*ast.Ident)
+	return ok && id.Name == name && id.Obj == nil
+}
+
+// Returns the name of the imported package in the file, or the default one when the file doesn't import it.
+func importName(file *ast.File, importPath string) string {
+	for _, spec := range file.Imports {
+		if p, _ := strconv.Unquote(spec.Path.Value); p == importPath && spec.Name != nil && spec.Name.Name != "_" && spec.Name.Name != "." {
+			return spec.Name.Name
+		}
+	}
+	return path.Base(importPath)
+}
+
+func hasImport(file *ast.File, importPath string) bool {
+	for _, spec := range file.Imports {
+		if p, _ := strconv.Unquote(spec.Path.Value); p == importPath {
+			return true
+		}
+	}
+	return false
+}
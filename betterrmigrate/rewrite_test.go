@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "write the golden files instead of comparing them")
+
+// Migrates the sample packages of testdata, and compares the result with their golden files.
+func TestMigrate(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*", "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		t.Run(filepath.ToSlash(file), func(t *testing.T) {
+			src, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var warnings bytes.Buffer
+			output, changed, err := migrate(file, src, &warnings)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !changed {
+				t.Errorf("\nExpected %s to be migrated", file)
+			}
+			// The warnings are part of the golden file, as a trailing comment
+			actual := string(output)
+			if warnings.Len() > 0 {
+				actual += "\n/* Warnings:\n" + warnings.String() + "*/\n"
+			}
+			golden := file + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, []byte(actual), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("\nCannot read golden file, run the test with -update to create it: %v", err)
+			}
+			if actual != string(expected) {
+				t.Errorf("\nOutput differs from golden file %s:\nExpected:\n%s\nActual:\n%s", golden, expected, actual)
+			}
+		})
+	}
+}
+
+func TestMigrate_Unchanged(t *testing.T) {
+	src := "package main\n\nimport \"errors\"\n\nvar err = errors.New(\"std\")\n"
+	output, changed, err := migrate("main.go", []byte(src), new(bytes.Buffer))
+	if err != nil || changed || string(output) != src {
+		t.Errorf("\nExpected the file to be left as is, got changed=%v err=%v:\n%s", changed, err, output)
+	}
+}
+
+func TestMigrate_ShadowedPackage(t *testing.T) {
+	src := `package main
+
+import "github.com/pkg/errors"
+
+type wrapper struct{}
+
+func (wrapper) Wrap(err error, msg string) error { return err }
+
+func run(err error) error {
+	errors := wrapper{}
+	return errors.Wrap(err, "shadowed")
+}
+
+var _ = errors.New
+`
+	output, changed, err := migrate("main.go", []byte(src), new(bytes.Buffer))
+	if err != nil || changed || string(output) != src {
+		t.Errorf("\nExpected the file to be left as is, got changed=%v err=%v:\n%s", changed, err, output)
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	src := "package main\n\nimport \"github.com/pkg/errors\"\n\nvar err = errors.New(\"failed\")\n"
+	for _, name := range []string{"main.go", "vendor/lib/lib.go", "README.md"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-l", dir + "/..."}, &stdout, &stderr); code != 0 {
+		t.Fatalf("\nExit code %d: %s", code, stderr.String())
+	}
+	if stdout.String() != filepath.Join(dir, "main.go")+"\n" {
+		t.Errorf("\nUnexpected files listed:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"-w", dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("\nExit code %d: %s", code, stderr.String())
+	}
+	migrated, _ := os.ReadFile(filepath.Join(dir, "main.go"))
+	expected := "package main\n\nimport \"github.com/jjunac/betterr\"\n\nvar err = betterr.New(\"failed\")\n"
+	if string(migrated) != expected {
+		t.Errorf("\nExpected:\n%s\nActual:\n%s", expected, migrated)
+	}
+	vendored, _ := os.ReadFile(filepath.Join(dir, "vendor", "lib", "lib.go"))
+	if string(vendored) != src {
+		t.Errorf("\nExpected the vendored file to be left as is:\n%s", vendored)
+	}
+	if stdout.Len() > 0 {
+		t.Errorf("\nUnexpected output with -w:\n%s", stdout.String())
+	}
+}
+
+func TestRun_InvalidFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{dir}, &stdout, &stderr); code != 1 {
+		t.Errorf("\nExpected exit code 1, got %d", code)
+	}
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/jjunac/betterr"
+	"github.com/rotisserie/eris"
+)
+
+var errForbidden = betterr.New("forbidden")
+
+func handle(r *http.Request) error {
+	if r.Header.Get("Authorization") == "" {
+		return eris.Wrap(errForbidden, "missing authorization")
+	}
+	if err := r.ParseForm(); err != nil {
+		return eris.Wrapf(err, "cannot parse the form of %s", r.URL)
+	}
+	if r.Method != http.MethodGet {
+		return eris.Errorf("unsupported method %s", r.Method)
+	}
+	return nil
+}
+
+func status(err error) int {
+	if eris.Is(err, errForbidden) {
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+func log(err error) {
+	// Not rewritten, eris formats its errors differently
+	_ = eris.ToString(err, true)
+}
@@ -0,0 +1,18 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/joomcode/errorx"
+)
+
+var ErrTimeout = errorx.IllegalState.New("timeout")
+
+func call(name string, attempt int, errs []error) error {
+	err := fmt.Errorf("%d errors", len(errs))
+	if attempt > 3 {
+		return errorx.Decorate(err, "call %s failed after %d attempts", name, attempt)
+	}
+	err = errorx.EnhanceStackTrace(err, "retrying")
+	return errorx.Decorate(err, "call failed")
+}
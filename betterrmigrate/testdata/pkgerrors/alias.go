@@ -0,0 +1,16 @@
+package store
+
+import (
+	"errors"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+var errClosed = errors.New("closed")
+
+func check(closed bool) error {
+	if closed {
+		return pkgerrors.WithMessagef(errClosed, "store %s", "users")
+	}
+	return nil
+}
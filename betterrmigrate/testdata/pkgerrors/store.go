@@ -0,0 +1,46 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+var ErrNotFound = errors.New("not found")
+
+type Store struct {
+	db *sql.DB
+}
+
+// Loads the user, or returns ErrNotFound.
+func (s *Store) Load(id int) (string, error) {
+	var name string
+	err := s.db.QueryRow("SELECT name FROM users WHERE id = ?", id).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", errors.WithStack(ErrNotFound)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot load user %d", id)
+	}
+	if name == "" {
+		return "", errors.Errorf("user %d has no name", id)
+	}
+	return name, nil
+}
+
+func (s *Store) Delete(id int) error {
+	_, err := s.db.Exec("DELETE FROM users WHERE id = ?", id)
+	return errors.Wrap(err, "cannot delete user")
+}
+
+func IsNotFound(err error) bool {
+	return errors.Cause(err) == ErrNotFound
+}
+
+func describe(err error) string {
+	if stackErr, ok := err.(interface{ StackTrace() errors.StackTrace }); ok {
+		return fmt.Sprintf("%+v", stackErr.StackTrace())
+	}
+	return errors.WithMessage(err, "no stack").Error()
+}
@@ -0,0 +1,127 @@
+// Package betterrotel integrates BetterErrors with OpenTelemetry traces.
+//
+// [RecordError] records an error on a span as an OpenTelemetry exception event,
+// and [New], [Errorf], [Decorate] and [Decoratef] create BetterErrors carrying the IDs
+// of the span active in the context, so the formatted errors can be correlated with the traces.
+package betterrotel
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/jjunac/betterr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Keys of the attributes holding the IDs of the span active when the error was created.
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// Prefix of the span event attributes holding the attributes of the error chain.
+const attributePrefix = "betterr."
+
+// Records the error on the span as an exception event and sets the span status to Error.
+// The exception message is the messages of the whole chain in Go style,
+// and the stack trace is the whole chain rendered by [betterr.JavaStyleFormatter].
+// The attributes of the chain are added to the event, prefixed with "betterr.", after applying the [betterr.Redactors].
+// Recording a nil error does nothing.
+func RecordError(span trace.Span, err error, options ...trace.EventOption) {
+	if err == nil || !span.IsRecording() {
+		return
+	}
+	message := new(betterr.GoStyleFormatter).Format(err)
+	attrs := []attribute.KeyValue{
+		semconv.ExceptionType(reflect.TypeOf(err).String()),
+		semconv.ExceptionMessage(message),
+		semconv.ExceptionStacktrace(new(betterr.JavaStyleFormatter).Format(err)),
+	}
+	attrs = append(attrs, chainAttributes(err)...)
+	span.AddEvent(semconv.ExceptionEventName, append(options, trace.WithAttributes(attrs...))...)
+	span.SetStatus(codes.Error, message)
+}
+
+// Returns the attributes of the chain, the outermost value wins when a key is attached several times.
+func chainAttributes(err error) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	seen := map[string]bool{}
+	for curr := err; curr != nil; {
+		betterErr, ok := curr.(*betterr.BetterError)
+		if !ok {
+			break
+		}
+		for _, attr := range betterr.Redact(betterErr).Attrs {
+			if seen[attr.Key] {
+				continue
+			}
+			seen[attr.Key] = true
+			attrs = append(attrs, toAttribute(attributePrefix+attr.Key, attr.Value))
+		}
+		curr = betterErr.Wrapped
+	}
+	return attrs
+}
+
+func toAttribute(key string, value any) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	case fmt.Stringer:
+		return attribute.Stringer(key, v)
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
+
+// Same as [betterr.New], but the error carries the IDs of the span active in the context.
+func New(ctx context.Context, msg string) error {
+	return withSpanAttrs(ctx, betterr.NewSkip(1, msg))
+}
+
+// Same as [betterr.Errorf], but the error carries the IDs of the span active in the context.
+func Errorf(ctx context.Context, format string, args ...any) error {
+	return withSpanAttrs(ctx, betterr.ErrorfSkip(1, format, args...))
+}
+
+// Same as [betterr.Decorate], but the error carries the IDs of the span active in the context.
+func Decorate(ctx context.Context, err error, msg string) error {
+	return withSpanAttrs(ctx, betterr.DecorateSkip(1, err, msg))
+}
+
+// Same as [betterr.Decoratef], but the error carries the IDs of the span active in the context.
+func Decoratef(ctx context.Context, err error, format string, args ...any) error {
+	return withSpanAttrs(ctx, betterr.DecoratefSkip(1, err, format, args...))
+}
+
+// Attaches the IDs of the span to the error freshly created for the caller, which is not shared yet and can be modified in place.
+func withSpanAttrs(ctx context.Context, err error) error {
+	if betterErr, ok := err.(*betterr.BetterError); ok {
+		betterErr.Attrs = spanAttrs(ctx)
+	}
+	return err
+}
+
+// Returns the attributes identifying the span active in the context, if any.
+func spanAttrs(ctx context.Context) []betterr.Attr {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.IsValid() {
+		return nil
+	}
+	return []betterr.Attr{
+		{Key: TraceIDKey, Value: spanCtx.TraceID().String()},
+		{Key: SpanIDKey, Value: spanCtx.SpanID().String()},
+	}
+}
@@ -0,0 +1,123 @@
+package betterrotel
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jjunac/betterr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTracer(t *testing.T) (*tracetest.InMemoryExporter, *sdktrace.TracerProvider) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+	return exporter, provider
+}
+
+func eventAttributes(attrs []attribute.KeyValue) map[string]string {
+	m := map[string]string{}
+	for _, attr := range attrs {
+		m[string(attr.Key)] = attr.Value.Emit()
+	}
+	return m
+}
+
+func TestRecordError(t *testing.T) {
+	exporter, provider := newTracer(t)
+	_, span := provider.Tracer("test").Start(context.Background(), "operation")
+
+	err := betterr.Decorate(
+		betterr.WithAttrs(betterr.Wrap(errors.New("connection refused")), betterr.Attr{Key: "host", Value: "db"}),
+		"cannot load user")
+	RecordError(span, err)
+	span.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("\nExpected 1 span, got %d", len(spans))
+	}
+	if spans[0].Status.Code != codes.Error || spans[0].Status.Description != "cannot load user: connection refused" {
+		t.Errorf("\nUnexpected status: %+v", spans[0].Status)
+	}
+	if len(spans[0].Events) != 1 || spans[0].Events[0].Name != "exception" {
+		t.Fatalf("\nExpected an exception event, got %+v", spans[0].Events)
+	}
+	attrs := eventAttributes(spans[0].Events[0].Attributes)
+	if attrs["exception.type"] != "*betterr.BetterError" {
+		t.Errorf("\nUnexpected exception.type: %s", attrs["exception.type"])
+	}
+	if attrs["exception.message"] != "cannot load user: connection refused" {
+		t.Errorf("\nUnexpected exception.message: %s", attrs["exception.message"])
+	}
+	if attrs["exception.stacktrace"] != new(betterr.JavaStyleFormatter).Format(err) {
+		t.Errorf("\nUnexpected exception.stacktrace: %s", attrs["exception.stacktrace"])
+	}
+	if !strings.Contains(attrs["exception.stacktrace"], "Caused by: connection refused\n    at github.com/jjunac/betterr/betterrotel.TestRecordError") {
+		t.Errorf("\nExpected the stack of the cause in: %s", attrs["exception.stacktrace"])
+	}
+	if attrs["betterr.host"] != "db" {
+		t.Errorf("\nExpected the attributes of the chain, got %v", attrs)
+	}
+}
+
+func TestRecordError_Nil(t *testing.T) {
+	exporter, provider := newTracer(t)
+	_, span := provider.Tracer("test").Start(context.Background(), "operation")
+	RecordError(span, nil)
+	span.End()
+
+	if events := exporter.GetSpans()[0].Events; len(events) != 0 {
+		t.Errorf("\nExpected no event, got %+v", events)
+	}
+}
+
+func TestNew_CarriesSpanIDs(t *testing.T) {
+	exporter, provider := newTracer(t)
+	ctx, span := provider.Tracer("test").Start(context.Background(), "operation")
+	err := Decoratef(ctx, New(ctx, "not found"), "cannot load user %d", 42)
+	span.End()
+
+	spanCtx := exporter.GetSpans()[0].SpanContext
+	for _, layer := range []*betterr.BetterError{err.(*betterr.BetterError), err.(*betterr.BetterError).Wrapped.(*betterr.BetterError)} {
+		if len(layer.Attrs) != 2 || layer.Attrs[0].Value != spanCtx.TraceID().String() || layer.Attrs[1].Value != spanCtx.SpanID().String() {
+			t.Errorf("\nUnexpected attributes: %v", layer.Attrs)
+		}
+		if fn := layer.Stack.GetFrames()[0].Function; fn != "github.com/jjunac/betterr/betterrotel.TestNew_CarriesSpanIDs" {
+			t.Errorf("\nExpected the stack to start at the caller, got %s", fn)
+		}
+	}
+	if msg := err.(*betterr.BetterError).Message(); msg != "cannot load user 42" {
+		t.Errorf("\nUnexpected message: %s", msg)
+	}
+	json := new(betterr.JsonFormatter).Format(err)
+	if !strings.Contains(json, `"trace_id":"`+spanCtx.TraceID().String()+`"`) {
+		t.Errorf("\nExpected the trace ID in the JSON output: %s", json)
+	}
+}
+
+func TestNew_WithoutSpan(t *testing.T) {
+	err := Errorf(context.Background(), "not %s", "found")
+	if attrs := err.(*betterr.BetterError).Attrs; len(attrs) != 0 {
+		t.Errorf("\nExpected no attributes, got %v", attrs)
+	}
+	if Decorate(context.Background(), nil, "msg") != nil || Decoratef(context.Background(), nil, "msg") != nil {
+		t.Errorf("\nExpected nil when decorating nil")
+	}
+}
+
+func TestErrorf_WrapVerb(t *testing.T) {
+	cause := errors.New("no rows")
+	err := Errorf(context.Background(), "cannot load user %d: %w", 42, cause).(*betterr.BetterError)
+	if err.Wrapped != cause || err.Message() != "cannot load user 42" {
+		t.Errorf("\nExpected the cause to be wrapped, got %q wrapping %v", err.Message(), err.Wrapped)
+	}
+	if fn := err.Stack.GetFrames()[0].Function; fn != "github.com/jjunac/betterr/betterrotel.TestErrorf_WrapVerb" {
+		t.Errorf("\nExpected the stack to start at the caller, got %s", fn)
+	}
+}
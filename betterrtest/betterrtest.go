@@ -0,0 +1,169 @@
+// Package betterrtest provides assertions and helpers to test code returning BetterErrors.
+//
+// [AssertChain] and [AssertOrigin] check the content of an error chain, [AssertGolden] and [AssertSnapshot] compare
+// the output of a formatter with a golden file after normalizing the parts that depend on the machine and the Go version,
+// and [FakeStacktrace] and [UseStableStacktraces] make the stacks of the errors deterministic.
+//
+// Set [Update], e.g. from an -update flag of the tests, or the BETTERR_UPDATE_GOLDEN environment variable to write
+// the golden files instead of comparing them:
+//   BETTERR_UPDATE_GOLDEN=1 go test ./...
+package betterrtest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/jjunac/betterr"
+)
+
+// Asserts that the messages of the errors of the chain are the expected ones, from the outermost error to the root cause.
+// The message of a BetterError is its own message, without the message of its causes, see [betterr.BetterError.Message].
+// For joined errors, the errors are listed in depth-first order, see [betterr.Chain].
+func AssertChain(t testing.TB, err error, messages ...string) {
+	t.Helper()
+	var actual []string
+	for _, layer := range betterr.Chain(err) {
+		actual = append(actual, Message(layer))
+	}
+	if fmt.Sprintf("%q", actual) != fmt.Sprintf("%q", messages) {
+		t.Errorf("\nUnexpected error chain:\nExpected: %q\nActual: %q", messages, actual)
+	}
+}
+
+// Returns the message of the error, without the message of its causes for BetterErrors.
+func Message(err error) string {
+	if betterErr, ok := err.(*betterr.BetterError); ok {
+		return betterErr.Message()
+	}
+	return err.Error()
+}
+
+// Asserts that the error originated, i.e. its deepest BetterError was created, in the expected function and file.
+// The function can be fully qualified ("github.com/myapp.(*Worker).Run") or not ("(*Worker).Run", "Run"),
+// and the file can be a path suffix ("worker.go", "myapp/worker.go"). An empty file is not checked.
+func AssertOrigin(t testing.TB, err error, function, file string) {
+	t.Helper()
+	stack := betterr.StackOf(err)
+	if stack == nil || len(stack.GetFrames()) == 0 {
+		t.Errorf("\nExpected the error to originate in %s, but it has no stack: %v", function, err)
+		return
+	}
+	frame := stack.GetFrames()[0]
+	if frame.Function != function && !strings.HasSuffix(frame.Function, "."+function) {
+		t.Errorf("\nUnexpected origin function:\nExpected: %s\nActual: %s", function, frame.Function)
+	}
+	if file != "" && frame.File != file && !strings.HasSuffix(frame.File, "/"+file) {
+		t.Errorf("\nUnexpected origin file:\nExpected: %s\nActual: %s", file, frame.File)
+	}
+}
+
+var (
+	// Go files with their directories, e.g. "/home/ci/go/pkg/mod/github.com/lib/pq@v1.10.0/conn.go".
+	// The escape preceding a path in a JSON string, e.g. "\n\t/src/myapp/file.go", is kept
+	goFilePaths = regexp.MustCompile(`(\\[nrt])?(?:[A-Za-z]:)?[^\s():"'\[\]\\]*/([^/\s():"'\[\]]+\.go)\b`)
+	goFileLines = regexp.MustCompile(`(\.go):\d+`)
+	jsonLines   = regexp.MustCompile(`("line":\s*)\d+`)
+	// Frames of the runtime and of the testing package, in the Java style and in the Go panic style
+	runtimeFrames = regexp.MustCompile(`(?m)^\s*(?:at )?(?:runtime|testing)\.\S+.*\n(?:\t.*\n)?`)
+)
+
+// Normalizes the output of a formatter, so it doesn't depend on the machine and the Go version:
+// the paths of the Go files are replaced by their base name, their lines by "N" (0 in JSON),
+// and the frames of the runtime and of the testing package are removed from the text formats.
+func Normalize(output string) string {
+	output = goFilePaths.ReplaceAllString(output, "$1$2")
+	output = goFileLines.ReplaceAllString(output, "$1:N")
+	output = jsonLines.ReplaceAllString(output, "${1}0")
+	return runtimeFrames.ReplaceAllString(output, "")
+}
+
+// Update makes [AssertGolden] write the golden files instead of comparing them.
+// It is set when the BETTERR_UPDATE_GOLDEN environment variable is not empty, and can be bound to a flag of the tests,
+// e.g. with flag.BoolVar(&betterrtest.Update, "update", betterrtest.Update, "write the golden files") in an init function.
+var Update = os.Getenv("BETTERR_UPDATE_GOLDEN") != ""
+
+// Asserts that the normalized output is the content of the golden file (see [Normalize]).
+// With [Update], the golden file is written instead.
+func AssertGolden(t testing.TB, goldenFile, output string) {
+	t.Helper()
+	actual := Normalize(output)
+	if Update {
+		if err := os.MkdirAll(filepath.Dir(goldenFile), 0o755); err != nil {
+			t.Fatalf("\nCannot create the directory of golden file %s: %v", goldenFile, err)
+		}
+		if err := os.WriteFile(goldenFile, []byte(actual), 0o644); err != nil {
+			t.Fatalf("\nCannot write golden file %s: %v", goldenFile, err)
+		}
+		return
+	}
+	expected, err := os.ReadFile(goldenFile)
+	if err != nil {
+		t.Errorf("\nCannot read golden file %s, run the test with -update or BETTERR_UPDATE_GOLDEN=1 to create it: %v\nActual output:\n%s", goldenFile, err, actual)
+		return
+	}
+	if !bytes.Equal(expected, []byte(actual)) {
+		t.Errorf("\nOutput differs from golden file %s, run the test with -update or BETTERR_UPDATE_GOLDEN=1 to accept it:\nExpected:\n%s\nActual:\n%s", goldenFile, expected, actual)
+	}
+}
+
+// Asserts that the output of the formatter for the error matches the golden file of the test, see [AssertSnapshot].
+func AssertFormat(t testing.TB, formatter betterr.ErrorFormatter, err error) {
+	t.Helper()
+	AssertSnapshot(t, formatter.Format(err))
+}
+
+// Asserts that the normalized output matches the golden file of the test, "testdata/<test name>.golden", see [AssertGolden].
+// Subtests have their golden file in the directory of their parent test, e.g. "testdata/TestFormat/java.golden".
+func AssertSnapshot(t testing.TB, output string) {
+	t.Helper()
+	AssertGolden(t, GoldenFile(t), output)
+}
+
+// Returns the golden file of the test used by [AssertSnapshot].
+func GoldenFile(t testing.TB) string {
+	return filepath.Join("testdata", filepath.FromSlash(t.Name())+".golden")
+}
+
+// Returns a stack trace made of frames of the provided functions, the first one being the innermost.
+// Their files and lines are deterministic, e.g. "github.com/myapp.(*Worker).Run" gives "/src/github.com/myapp/myapp.go:10".
+func FakeStacktrace(functions ...string) betterr.Stacktrace {
+	frames := make([]betterr.StackFrames, len(functions))
+	for i, function := range functions {
+		pkg, _ := betterr.SplitFunctionName(function)
+		if pkg == "" {
+			pkg = "main"
+		}
+		frames[i] = betterr.StackFrames{
+			Function: function,
+			File:     "/src/" + pkg + "/" + path.Base(pkg) + ".go",
+			Line:     10 * (i + 1),
+		}
+	}
+	return &betterr.StaticStacktrace{Frames: frames}
+}
+
+// Replaces [betterr.GetStacktrace] until the end of the test, so the stacks of the errors created by the test are stable
+// across machines and Go versions: the frames of the standard library are dropped, and the files are relative to their module.
+func UseStableStacktraces(t testing.TB) {
+	previous := betterr.GetStacktrace
+	betterr.GetStacktrace = func(skip int) betterr.Stacktrace {
+		var frames []betterr.StackFrames
+		for _, frame := range previous(skip + 1).GetFrames() {
+			if frame.IsStd() {
+				continue
+			}
+			frame.File = frame.Path(betterr.RelativePath)
+			frames = append(frames, frame)
+		}
+		return &betterr.StaticStacktrace{Frames: frames}
+	}
+	t.Cleanup(func() {
+		betterr.GetStacktrace = previous
+	})
+}
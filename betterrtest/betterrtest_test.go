@@ -0,0 +1,166 @@
+package betterrtest
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jjunac/betterr"
+)
+
+func init() {
+	flag.BoolVar(&Update, "update", Update, "write the golden files instead of comparing them")
+}
+
+// Records the failures of the assertions instead of failing the test.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func expectFailures(t *testing.T, expected int, assert func(tb testing.TB)) {
+	t.Helper()
+	r := &recorder{TB: t}
+	assert(r)
+	if len(r.failures) != expected {
+		t.Errorf("\nExpected %d failures, got %d: %q", expected, len(r.failures), r.failures)
+	}
+}
+
+func loadUser() error {
+	return betterr.Decorate(betterr.Errorf("user %d not found: %w", 42, io.EOF), "cannot load user")
+}
+
+func TestAssertChain(t *testing.T) {
+	expectFailures(t, 0, func(tb testing.TB) {
+		AssertChain(tb, loadUser(), "cannot load user", "user 42 not found", "EOF")
+		AssertChain(tb, nil)
+	})
+	expectFailures(t, 1, func(tb testing.TB) {
+		AssertChain(tb, loadUser(), "cannot load user", "user 42 not found")
+	})
+	expectFailures(t, 1, func(tb testing.TB) {
+		AssertChain(tb, errors.New("a"), "b")
+	})
+}
+
+func TestAssertOrigin(t *testing.T) {
+	expectFailures(t, 0, func(tb testing.TB) {
+		AssertOrigin(tb, loadUser(), "github.com/jjunac/betterr/betterrtest.loadUser", "betterrtest_test.go")
+		AssertOrigin(tb, loadUser(), "loadUser", "betterrtest/betterrtest_test.go")
+		AssertOrigin(tb, loadUser(), "loadUser", "")
+	})
+	expectFailures(t, 2, func(tb testing.TB) {
+		AssertOrigin(tb, loadUser(), "TestAssertOrigin", "other.go")
+	})
+	expectFailures(t, 1, func(tb testing.TB) {
+		AssertOrigin(tb, io.EOF, "loadUser", "")
+	})
+}
+
+func TestNormalize(t *testing.T) {
+	output := "cannot load user\n" +
+		"    at github.com/myapp.Load (/home/ci/src/myapp/load.go:12)\n" +
+		"    at testing.tRunner (/usr/local/go/src/testing/testing.go:1595)\n" +
+		"    at runtime.goexit (/usr/local/go/src/runtime/asm_amd64.s:1650)\n" +
+		"Caused by: EOF\n" +
+		"goroutine 1 [running]:\n" +
+		"github.com/myapp.Load(...)\n" +
+		"\t/home/ci/go/pkg/mod/github.com/myapp@v1.0.0/load.go:12\n" +
+		"runtime.main()\n" +
+		"\t/usr/local/go/src/runtime/proc.go:250\n" +
+		`{"file":"/home/ci/src/myapp/load.go","line":12}`
+	expected := "cannot load user\n" +
+		"    at github.com/myapp.Load (load.go:N)\n" +
+		"Caused by: EOF\n" +
+		"goroutine 1 [running]:\n" +
+		"github.com/myapp.Load(...)\n" +
+		"\tload.go:N\n" +
+		`{"file":"load.go","line":0}`
+	if actual := Normalize(output); actual != expected {
+		t.Errorf("\nExpected:\n%s\nActual:\n%s", expected, actual)
+	}
+}
+
+func TestAssertGolden(t *testing.T) {
+	// Compares the golden files even when the tests are run with -update
+	defer func(update bool) { Update = update }(Update)
+	Update = false
+	err := &betterr.BetterError{
+		Msg:     "cannot load user",
+		Stack:   FakeStacktrace("github.com/myapp.(*Loader).Load", "github.com/myapp.main"),
+		Wrapped: io.EOF,
+	}
+	expectFailures(t, 0, func(tb testing.TB) {
+		AssertGolden(tb, "testdata/java_style.golden", new(betterr.JavaStyleFormatter).Format(err))
+	})
+	expectFailures(t, 1, func(tb testing.TB) {
+		AssertGolden(tb, "testdata/java_style.golden", "other output")
+	})
+	expectFailures(t, 1, func(tb testing.TB) {
+		AssertGolden(tb, "testdata/missing.golden", "output")
+	})
+}
+
+func TestFakeStacktrace(t *testing.T) {
+	frames := FakeStacktrace("github.com/myapp/worker.(*Worker).Run", "main.main").GetFrames()
+	if frames[0].File != "/src/github.com/myapp/worker/worker.go" || frames[0].Line != 10 {
+		t.Errorf("\nUnexpected frame: %+v", frames[0])
+	}
+	if frames[1].File != "/src/main/main.go" || frames[1].Line != 20 {
+		t.Errorf("\nUnexpected frame: %+v", frames[1])
+	}
+}
+
+func TestUseStableStacktraces(t *testing.T) {
+	UseStableStacktraces(t)
+	frames := betterr.StackOf(loadUser()).GetFrames()
+	if len(frames) != 2 {
+		t.Fatalf("\nExpected the frames of the test only, got %+v", frames)
+	}
+	if frames[0].Function != "github.com/jjunac/betterr/betterrtest.loadUser" || frames[0].File != "betterrtest/betterrtest_test.go" {
+		t.Errorf("\nUnexpected frame: %+v", frames[0])
+	}
+	if frames[1].Function != "github.com/jjunac/betterr/betterrtest.TestUseStableStacktraces" {
+		t.Errorf("\nUnexpected frame: %+v", frames[1])
+	}
+}
+
+func TestAssertSnapshot(t *testing.T) {
+	err := betterr.WithAttrs(&betterr.BetterError{
+		Msg:   "cannot load user",
+		Stack: FakeStacktrace("github.com/myapp.(*Loader).Load"),
+	}, betterr.Attr{Key: "id", Value: 42})
+	for name, formatter := range map[string]betterr.ErrorFormatter{
+		"go":   &betterr.GoStyleFormatter{},
+		"java": &betterr.JavaStyleFormatter{},
+		"json": &betterr.JsonFormatter{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			AssertFormat(t, formatter, err)
+		})
+	}
+	if file := GoldenFile(t); file != "testdata/TestAssertSnapshot.golden" {
+		t.Errorf("\nUnexpected golden file: %s", file)
+	}
+}
+
+func TestAssertGolden_Update(t *testing.T) {
+	defer func(update bool) { Update = update }(Update)
+	Update = true
+	goldenFile := filepath.Join(t.TempDir(), "dir", "output.golden")
+	AssertGolden(t, goldenFile, "at github.com/myapp.Load (/src/myapp/load.go:12)")
+	if content, _ := os.ReadFile(goldenFile); string(content) != "at github.com/myapp.Load (load.go:N)" {
+		t.Errorf("\nUnexpected golden file content: %s", content)
+	}
+}
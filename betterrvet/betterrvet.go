@@ -0,0 +1,436 @@
+// Package betterrvet provides a static analyzer reporting the mistakes commonly made when handling errors with BettErr:
+//   - returning an error created by errors.New or fmt.Errorf, without stack trace, in a package using BettErr
+//   - decorating an error already decorated in the same function, whose stack trace already points there
+//   - comparing the messages of errors instead of the errors themselves
+//   - ignoring the result of betterr.Wrap, Decorate or WithAttrs, which return a new error
+//   - comparing BetterErrors with ==, which ignores their decorations
+//
+// The first two checks skip the test files, which fake the plain errors of other libraries and build the chains they check.
+//
+// The analyzer can be run with go vet, see the betterrvet command:
+//   go install github.com/jjunac/betterr/betterrvet/cmd/betterrvet@latest
+//   go vet -vettool=$(which betterrvet) ./...
+package betterrvet
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const betterrPath = "github.com/jjunac/betterr"
+
+var Analyzer = &analysis.Analyzer{
+	Name:      "betterr",
+	Doc:       "reports the mistakes commonly made when handling errors with BettErr",
+	URL:       "https://pkg.go.dev/github.com/jjunac/betterr/betterrvet",
+	Requires:  []*analysis.Analyzer{inspect.Analyzer},
+	Run:       run,
+	FactTypes: []analysis.Fact{new(isBetterError)},
+}
+
+// Fact of the package-level variables holding a BetterError, e.g. var ErrNotFound = betterr.New("not found").
+type isBetterError struct{}
+
+func (*isBetterError) AFact() {}
+
+func (*isBetterError) String() string {
+	return "isBetterError"
+}
+
+// Functions of the betterr package returning their error without modifying their argument.
+var resultFunctions = map[string]bool{"Wrap": true, "Decorate": true, "Decoratef": true, "WithAttrs": true}
+
+// Standard functions creating errors without stack trace, and their BettErr equivalent.
+var plainConstructors = map[string]string{"errors.New": "New", "fmt.Errorf": "Errorf"}
+
+func run(pass *analysis.Pass) (any, error) {
+	if pass.Pkg.Path() == betterrPath {
+		// The implementation of BettErr is allowed to compare its errors
+		return nil, nil
+	}
+	exportSentinels(pass)
+	optedIn := false
+	for _, imported := range pass.Pkg.Imports() {
+		optedIn = optedIn || imported.Path() == betterrPath
+	}
+
+	reported := map[token.Pos]bool{}
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	nodeFilter := []ast.Node{
+		(*ast.File)(nil),
+		(*ast.ReturnStmt)(nil),
+		(*ast.BlockStmt)(nil),
+		(*ast.CaseClause)(nil),
+		(*ast.CommClause)(nil),
+		(*ast.ExprStmt)(nil),
+		(*ast.AssignStmt)(nil),
+		(*ast.BinaryExpr)(nil),
+		(*ast.SwitchStmt)(nil),
+	}
+	var file *ast.File
+	// Tests fake the plain errors of other libraries, and build the chains they check by decorating the errors they create
+	inTest := false
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.File:
+			file = n
+			inTest = strings.HasSuffix(pass.Fset.File(n.Pos()).Name(), "_test.go")
+		case *ast.ReturnStmt:
+			if optedIn && !inTest {
+				checkPlainReturn(pass, file, n)
+			}
+		case *ast.BlockStmt:
+			if !inTest {
+				checkRedecoration(pass, n.List, reported)
+			}
+		case *ast.CaseClause:
+			if !inTest {
+				checkRedecoration(pass, n.Body, reported)
+			}
+		case *ast.CommClause:
+			if !inTest {
+				checkRedecoration(pass, n.Body, reported)
+			}
+		case *ast.ExprStmt:
+			if call, ok := n.X.(*ast.CallExpr); ok {
+				checkUnusedResult(pass, call)
+			}
+		case *ast.AssignStmt:
+			if len(n.Lhs) == 1 && len(n.Rhs) == 1 && isBlank(n.Lhs[0]) {
+				if call, ok := n.Rhs[0].(*ast.CallExpr); ok {
+					checkUnusedResult(pass, call)
+				}
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.EQL || n.Op == token.NEQ {
+				checkComparison(pass, n, n.X, n.Y)
+			}
+		case *ast.SwitchStmt:
+			checkSwitch(pass, n)
+		}
+	})
+	return nil, nil
+}
+
+// Exports the fact of the package-level variables holding a BetterError, so they are known when comparing them in other packages.
+func exportSentinels(pass *analysis.Pass) {
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				valueSpec := spec.(*ast.ValueSpec)
+				if len(valueSpec.Values) != len(valueSpec.Names) {
+					continue
+				}
+				for i, name := range valueSpec.Names {
+					if obj := pass.TypesInfo.Defs[name]; obj != nil && createsStack(pass, valueSpec.Values[i]) {
+						pass.ExportObjectFact(obj, new(isBetterError))
+					}
+				}
+			}
+		}
+	}
+}
+
+func checkPlainReturn(pass *analysis.Pass, file *ast.File, ret *ast.ReturnStmt) {
+	for _, result := range ret.Results {
+		call, ok := ast.Unparen(result).(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		fn := callee(pass, call)
+		if fn == nil || fn.Pkg() == nil {
+			continue
+		}
+		replacement, ok := plainConstructors[fn.Pkg().Path()+"."+fn.Name()]
+		if !ok {
+			continue
+		}
+		diagnostic := analysis.Diagnostic{
+			Pos:     call.Pos(),
+			End:     call.End(),
+			Message: fn.Pkg().Name() + "." + fn.Name() + " returns an error without stack trace, use betterr." + replacement,
+		}
+		if name := importName(file, betterrPath); name != "" {
+			edits := []analysis.TextEdit{{Pos: call.Fun.Pos(), End: call.Fun.End(), NewText: []byte(name + "." + replacement)}}
+			diagnostic.SuggestedFixes = []analysis.SuggestedFix{{
+				Message:   "Use betterr." + replacement,
+				TextEdits: append(edits, removeLastUse(pass, file, fn.Pkg())...),
+			}}
+		}
+		pass.Report(diagnostic)
+	}
+}
+
+// Reports the decorations of errors already decorated, or created, by the previous statements of the block.
+// Only the statements of the block are tracked, as the errors decorated in a branch may not be in the others.
+func checkRedecoration(pass *analysis.Pass, stmts []ast.Stmt, reported map[token.Pos]bool) {
+	decorated := map[types.Object]bool{}
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncLit:
+				// Closures have their own frame
+				return false
+			case *ast.CallExpr:
+				arg := decoratedArg(pass, n)
+				if arg == nil || reported[n.Pos()] {
+					return true
+				}
+				if obj := localObject(pass, arg); (obj != nil && decorated[obj]) || createsStack(pass, arg) {
+					reported[n.Pos()] = true
+					pass.Reportf(n.Pos(), "error already decorated in this function, its stack trace already points here: add the context to the first decoration")
+				}
+			}
+			return true
+		})
+
+		// Any other assignment, including in the nested blocks, may replace the decorated error
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			assign, ok := n.(*ast.AssignStmt)
+			if !ok {
+				return true
+			}
+			for i, lhs := range assign.Lhs {
+				obj := localObject(pass, lhs)
+				if obj == nil {
+					continue
+				}
+				decorated[obj] = n == stmt && len(assign.Lhs) == len(assign.Rhs) && createsStack(pass, assign.Rhs[i])
+			}
+			return true
+		})
+		if decl, ok := stmt.(*ast.DeclStmt); ok {
+			if genDecl, ok := decl.Decl.(*ast.GenDecl); ok && genDecl.Tok == token.VAR {
+				for _, spec := range genDecl.Specs {
+					valueSpec := spec.(*ast.ValueSpec)
+					for i, name := range valueSpec.Names {
+						if len(valueSpec.Values) == len(valueSpec.Names) && createsStack(pass, valueSpec.Values[i]) {
+							decorated[pass.TypesInfo.Defs[name]] = true
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func checkUnusedResult(pass *analysis.Pass, call *ast.CallExpr) {
+	fn := callee(pass, call)
+	if isBetterrFunc(fn) && resultFunctions[fn.Name()] {
+		pass.Reportf(call.Pos(), "result of betterr.%s is not used, it returns a new error rather than modifying its argument", fn.Name())
+	}
+}
+
+func checkComparison(pass *analysis.Pass, node ast.Node, x, y ast.Expr) {
+	if isErrorMessage(pass, x) || isErrorMessage(pass, y) {
+		pass.Reportf(node.Pos(), "comparing the messages of errors is fragile, use errors.Is or errors.As")
+		return
+	}
+	if isNil(pass, x) || isNil(pass, y) {
+		return
+	}
+	if isBetterErrorExpr(pass, x) || isBetterErrorExpr(pass, y) {
+		pass.Reportf(node.Pos(), "comparing BetterErrors with == ignores their decorations, use errors.Is")
+	}
+}
+
+func checkSwitch(pass *analysis.Pass, switchStmt *ast.SwitchStmt) {
+	if switchStmt.Tag == nil {
+		return
+	}
+	if isErrorMessage(pass, switchStmt.Tag) {
+		pass.Reportf(switchStmt.Tag.Pos(), "comparing the messages of errors is fragile, use errors.Is or errors.As")
+		return
+	}
+	for _, stmt := range switchStmt.Body.List {
+		for _, value := range stmt.(*ast.CaseClause).List {
+			if isBetterErrorExpr(pass, value) {
+				pass.Reportf(value.Pos(), "comparing BetterErrors with == ignores their decorations, use errors.Is")
+			}
+		}
+	}
+}
+
+// Returns the error decorated by the call, if it is a call to betterr.Decorate, betterr.Decoratef or ErrorKind.Decorate.
+func decoratedArg(pass *analysis.Pass, call *ast.CallExpr) ast.Expr {
+	fn := callee(pass, call)
+	if !isBetterrFunc(fn) || len(call.Args) == 0 {
+		return nil
+	}
+	if fn.Name() == "Decorate" || (fn.Name() == "Decoratef" && !isMethod(fn)) {
+		return ast.Unparen(call.Args[0])
+	}
+	return nil
+}
+
+// Returns whether the expression is a call of betterr recording the stack trace of the calling function.
+func createsStack(pass *analysis.Pass, expr ast.Expr) bool {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	fn := callee(pass, call)
+	if !isBetterrFunc(fn) {
+		return false
+	}
+	switch fn.Name() {
+	case "New", "Decorate":
+		return true
+	case "Errorf", "Wrap", "Decoratef":
+		return !isMethod(fn)
+	}
+	return false
+}
+
+// Returns whether the expression is a call of the Error method of an error.
+func isErrorMessage(pass *analysis.Pass, expr ast.Expr) bool {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok || len(call.Args) != 0 {
+		return false
+	}
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || selector.Sel.Name != "Error" {
+		return false
+	}
+	t := pass.TypesInfo.TypeOf(selector.X)
+	errorType := types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
+	return t != nil && types.Implements(t, errorType)
+}
+
+// Returns whether the expression is a *betterr.BetterError, or a variable holding one.
+func isBetterErrorExpr(pass *analysis.Pass, expr ast.Expr) bool {
+	expr = ast.Unparen(expr)
+	if pointer, ok := pass.TypesInfo.TypeOf(expr).(*types.Pointer); ok {
+		if named, ok := pointer.Elem().(*types.Named); ok {
+			obj := named.Obj()
+			if obj.Pkg() != nil && obj.Pkg().Path() == betterrPath && obj.Name() == "BetterError" {
+				return true
+			}
+		}
+	}
+	obj := objectOf(pass, expr)
+	return obj != nil && pass.ImportObjectFact(obj, new(isBetterError))
+}
+
+func callee(pass *analysis.Pass, call *ast.CallExpr) *types.Func {
+	fn, _ := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	return fn
+}
+
+func isBetterrFunc(fn *types.Func) bool {
+	return fn != nil && fn.Pkg() != nil && fn.Pkg().Path() == betterrPath
+}
+
+func isMethod(fn *types.Func) bool {
+	return fn.Type().(*types.Signature).Recv() != nil
+}
+
+// Returns the variable referenced by the expression, or nil.
+func objectOf(pass *analysis.Pass, expr ast.Expr) types.Object {
+	var id *ast.Ident
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		id = e
+	case *ast.SelectorExpr:
+		id = e.Sel
+	default:
+		return nil
+	}
+	if v, ok := pass.TypesInfo.ObjectOf(id).(*types.Var); ok {
+		return v
+	}
+	return nil
+}
+
+// Returns the variable named by the identifier, or nil.
+// Unlike objectOf, the fields are ignored, as the same field of different values would be mixed up.
+func localObject(pass *analysis.Pass, expr ast.Expr) types.Object {
+	if id, ok := expr.(*ast.Ident); ok {
+		if v, ok := pass.TypesInfo.ObjectOf(id).(*types.Var); ok {
+			return v
+		}
+	}
+	return nil
+}
+
+func isNil(pass *analysis.Pass, expr ast.Expr) bool {
+	return pass.TypesInfo.Types[expr].IsNil()
+}
+
+func isBlank(expr ast.Expr) bool {
+	id, ok := expr.(*ast.Ident)
+	return ok && id.Name == "_"
+}
+
+// Returns the edit removing the import of the package when the file uses it only once, i.e. in the call being replaced,
+// as an unused import doesn't compile.
+func removeLastUse(pass *analysis.Pass, file *ast.File, pkg *types.Package) []analysis.TextEdit {
+	uses := 0
+	ast.Inspect(file, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			if pkgName, ok := pass.TypesInfo.Uses[id].(*types.PkgName); ok && pkgName.Imported() == pkg {
+				uses++
+			}
+		}
+		return true
+	})
+	if uses != 1 {
+		return nil
+	}
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.IMPORT {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			if importPath, _ := strconv.Unquote(spec.(*ast.ImportSpec).Path.Value); importPath != pkg.Path() {
+				continue
+			}
+			// The whole declaration goes with its last import
+			var node ast.Node = spec
+			if len(genDecl.Specs) == 1 {
+				node = genDecl
+			}
+			return []analysis.TextEdit{lineDeletion(pass.Fset.File(node.Pos()), node)}
+		}
+	}
+	return nil
+}
+
+// Returns the edit deleting the lines of the node, their newline included.
+func lineDeletion(tokFile *token.File, node ast.Node) analysis.TextEdit {
+	start := tokFile.LineStart(tokFile.Line(node.Pos()))
+	end := node.End()
+	if line := tokFile.Line(end); line < tokFile.LineCount() {
+		end = tokFile.LineStart(line + 1)
+	}
+	return analysis.TextEdit{Pos: start, End: end}
+}
+
+// Returns the name the file uses for the imported package, or "" when it doesn't import it by name.
+func importName(file *ast.File, path string) string {
+	for _, spec := range file.Imports {
+		if importPath, _ := strconv.Unquote(spec.Path.Value); importPath != path {
+			continue
+		}
+		if spec.Name == nil {
+			return "betterr"
+		}
+		if spec.Name.Name != "_" && spec.Name.Name != "." {
+			return spec.Name.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,16 @@
+// Command betterrvet reports the mistakes commonly made when handling errors with BettErr, see package betterrvet.
+//
+// Usage:
+//   betterrvet [-fix] ./...
+//   go vet -vettool=$(which betterrvet) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/jjunac/betterr/betterrvet"
+)
+
+func main() {
+	singlechecker.Main(betterrvet.Analyzer)
+}
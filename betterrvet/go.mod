@@ -0,0 +1,11 @@
+module github.com/jjunac/betterr/betterrvet
+
+// golang.org/x/tools requires Go 1.25, its older versions don't build with the recent Go releases
+go 1.25.0
+
+require golang.org/x/tools v0.44.0
+
+require (
+	golang.org/x/mod v0.35.0 // indirect
+	golang.org/x/sync v0.20.0 // indirect
+)
@@ -0,0 +1,17 @@
+package autofix
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jjunac/betterr"
+)
+
+var errLocal = betterr.New("local") // want errLocal:"isBetterError"
+
+func check(name string) error {
+	if name == "" {
+		return errors.New("empty name") // want `errors.New returns an error without stack trace, use betterr.New`
+	}
+	return fmt.Errorf("invalid name %q", name) // want `fmt.Errorf returns an error without stack trace, use betterr.Errorf`
+}
@@ -0,0 +1,11 @@
+package autofix
+
+import "errors"
+
+import "github.com/jjunac/betterr"
+
+var errSingle = betterr.New("single") // want errSingle:"isBetterError"
+
+func empty() error {
+	return errors.New("empty") // want `errors.New returns an error without stack trace, use betterr.New`
+}
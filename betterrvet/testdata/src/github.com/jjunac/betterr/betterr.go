@@ -0,0 +1,28 @@
+// Package betterr is a stub of the betterr package for the tests of the analyzer.
+package betterr
+
+type BetterError struct {
+	Msg     string
+	Wrapped error
+}
+
+func (e *BetterError) Error() string { return e.Msg }
+
+type Attr struct {
+	Key   string
+	Value any
+}
+
+func New(msg string) error                                  { return &BetterError{Msg: msg} }
+func Errorf(format string, args ...any) error               { return &BetterError{Msg: format} }
+func Wrap(err error) error                                  { return err }
+func Decorate(err error, msg string) error                  { return &BetterError{Msg: msg, Wrapped: err} }
+func Decoratef(err error, format string, args ...any) error { return &BetterError{Msg: format, Wrapped: err} }
+func WithAttrs(err error, attrs ...Attr) error              { return err }
+
+type ErrorKind[T any] struct{ name string }
+
+func Kind[T any](name string) *ErrorKind[T]                  { return &ErrorKind[T]{name: name} }
+func (k *ErrorKind[T]) Error() string                        { return k.name }
+func (k *ErrorKind[T]) New(details T) error                  { return &BetterError{Msg: k.name} }
+func (k *ErrorKind[T]) Decorate(err error, details T) error { return &BetterError{Msg: k.name, Wrapped: err} }
@@ -0,0 +1,96 @@
+package optin
+
+import (
+	"errors"
+	"fmt"
+	"sentinel"
+
+	"github.com/jjunac/betterr"
+)
+
+var errLocal = betterr.New("local") // want errLocal:"isBetterError"
+
+var errPlain = errors.New("plain")
+
+func load(name string) error {
+	if name == "" {
+		return errors.New("empty name") // want `errors.New returns an error without stack trace, use betterr.New`
+	}
+	if len(name) > 10 {
+		return fmt.Errorf("name %q too long", name) // want `fmt.Errorf returns an error without stack trace, use betterr.Errorf`
+	}
+	return errPlain
+}
+
+func redecorate(name string) error {
+	err := load(name)
+	if err != nil {
+		err = betterr.Decorate(err, "cannot load")
+		return betterr.Decoratef(err, "name %s", name) // want `error already decorated in this function`
+	}
+	created := betterr.New("created")
+	return betterr.Decorate(created, "more context") // want `error already decorated in this function`
+}
+
+func nested(err error) error {
+	return betterr.Decorate(betterr.Wrap(err), "context") // want `error already decorated in this function`
+}
+
+func branches(err error, retry bool) error {
+	if retry {
+		err = betterr.Decorate(err, "retry failed")
+	}
+	// Decorated in a branch only
+	err = betterr.Decorate(err, "cannot process")
+	err = load("")
+	// Replaced by another error
+	return betterr.Decorate(err, "cannot reload")
+}
+
+func closure(err error) func() error {
+	err = betterr.Decorate(err, "cannot start")
+	return func() error {
+		// The closure has its own frame
+		return betterr.Decorate(err, "cannot run")
+	}
+}
+
+func kinds(err error) error {
+	err = sentinel.ErrQuota.Decorate(err, 10)
+	return betterr.Decorate(err, "context") // want `error already decorated in this function`
+}
+
+func ignored(err error) {
+	betterr.Wrap(err)                                               // want `result of betterr.Wrap is not used`
+	betterr.Decorate(err, "context")                                // want `result of betterr.Decorate is not used`
+	_ = betterr.WithAttrs(err, betterr.Attr{Key: "key", Value: 42}) // want `result of betterr.WithAttrs is not used`
+	err = betterr.Wrap(err)
+	_ = err
+}
+
+func compare(err error, betterErr *betterr.BetterError) bool {
+	if err.Error() == "not found" { // want `comparing the messages of errors is fragile`
+		return true
+	}
+	switch err.Error() { // want `comparing the messages of errors is fragile`
+	case "not found":
+		return true
+	}
+	if err == sentinel.ErrNotFound || err != errLocal { // want `comparing BetterErrors with == ignores their decorations` `comparing BetterErrors with == ignores their decorations`
+		return true
+	}
+	switch err {
+	case sentinel.ErrNotFound: // want `comparing BetterErrors with == ignores their decorations`
+		return true
+	case errPlain, nil:
+		return false
+	}
+	if betterErr == nil || err == errPlain || err == sentinel.ErrQuota {
+		return false
+	}
+	return err == betterErr // want `comparing BetterErrors with == ignores their decorations`
+}
+
+func describe(err error) string {
+	return fmt.Sprintf("error: %v", err)
+}
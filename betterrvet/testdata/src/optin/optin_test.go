@@ -0,0 +1,20 @@
+package optin
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jjunac/betterr"
+)
+
+// Fakes an error of another library.
+func failingDriver() error {
+	return errors.New("connection refused")
+}
+
+func TestChain(t *testing.T) {
+	err := betterr.Decorate(betterr.New("not found"), "cannot load")
+	if err == nil || failingDriver() == nil {
+		t.Fatal("expected errors")
+	}
+}
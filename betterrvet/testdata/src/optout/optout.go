@@ -0,0 +1,18 @@
+// Package optout doesn't use BettErr, so its plain errors are not reported.
+package optout
+
+import (
+	"errors"
+	"fmt"
+)
+
+func find(id int) error {
+	if id < 0 {
+		return errors.New("invalid id")
+	}
+	return fmt.Errorf("%d not found", id)
+}
+
+func isInvalid(err error) bool {
+	return err.Error() == "invalid id" // want `comparing the messages of errors is fragile, use errors.Is or errors.As`
+}
@@ -0,0 +1,7 @@
+package sentinel
+
+import "github.com/jjunac/betterr"
+
+var ErrNotFound = betterr.New("not found")
+
+var ErrQuota = betterr.Kind[int]("quota exceeded")
@@ -0,0 +1,122 @@
+package betterr
+
+import "errors"
+
+// Same as errors.As, finds the first error in err's tree that matches target, and if one is found, sets target to that error value.
+// Besides error types, BetterErrors match a target of type *Stacktrace, which is set to their stack.
+func As(err error, target any) bool {
+	return errors.As(err, target)
+}
+
+// Same as errors.Unwrap, returns the error wrapped by err, or nil.
+// Joined errors (see errors.Join) wrap several errors and give nil, use [Chain] to walk the whole tree.
+func Unwrap(err error) error {
+	return errors.Unwrap(err)
+}
+
+// Returns the deepest error of the chain, i.e. the root cause.
+// For joined errors, the first error of the join is followed.
+// Returns nil for a nil error.
+func Cause(err error) error {
+	for err != nil {
+		causes := unwrapAll(err)
+		if len(causes) == 0 {
+			return err
+		}
+		err = causes[0]
+	}
+	return nil
+}
+
+// Returns the deepest BetterError of the tree, where the error originated, or nil if there is none.
+// When several BetterErrors are equally deep, the first one in depth-first order wins.
+func Origin(err error) *BetterError {
+	var origin *BetterError
+	originDepth := -1
+	walkTree(err, 0, func(curr error, depth int) {
+		if betterErr, ok := curr.(*BetterError); ok && depth > originDepth {
+			origin, originDepth = betterErr, depth
+		}
+	})
+	return origin
+}
+
+// Returns all the errors of the tree, in depth-first order, starting with err itself.
+// For a simple chain, it is the list of the layers from the outermost error to the root cause.
+func Chain(err error) []error {
+	var chain []error
+	walkTree(err, 0, func(curr error, _ int) {
+		chain = append(chain, curr)
+	})
+	return chain
+}
+
+// Returns the most relevant stack of the tree, which is the stack of its [Origin], or nil if there is none.
+// The stacks of the errors of other libraries, found by the [StackExtractors], are considered too: when such an error
+// is deeper than the origin, e.g. a github.com/pkg/errors error decorated by a BetterError, its stack is returned.
+func StackOf(err error) Stacktrace {
+	var stack Stacktrace
+	stackDepth := -1
+	walkTree(err, 0, func(curr error, depth int) {
+		if depth <= stackDepth {
+			return
+		}
+		if betterErr, ok := curr.(*BetterError); ok {
+			stack, stackDepth = betterErr.Stack, depth
+		} else if foreign := extractStack(curr); foreign != nil {
+			stack, stackDepth = foreign, depth
+		}
+	})
+	return stack
+}
+
+// Calls fn for err and all the errors it wraps, in depth-first order.
+func walkTree(err error, depth int, fn func(err error, depth int)) {
+	if err == nil {
+		return
+	}
+	fn(err, depth)
+	for _, cause := range unwrapAll(err) {
+		walkTree(cause, depth+1, fn)
+	}
+}
+
+// Returns the errors directly wrapped by err, whether it implements Unwrap() error or Unwrap() []error.
+func unwrapAll(err error) []error {
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		if cause := e.Unwrap(); cause != nil {
+			return []error{cause}
+		}
+	case interface{ Unwrap() []error }:
+		return e.Unwrap()
+	}
+	return nil
+}
+
+// Returns the errors of a join, i.e. an error wrapping several errors like the ones of errors.Join,
+// with the errors of the nested joins in place of the joins. Returns nil if the error is not a join.
+func joinMembers(err error) []error {
+	join, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return nil
+	}
+	members := []error{}
+	for _, member := range join.Unwrap() {
+		if nested := joinMembers(member); nested != nil {
+			members = append(members, nested...)
+		} else if member != nil {
+			members = append(members, member)
+		}
+	}
+	return members
+}
+
+// Implements errors.As for the targets specific to BetterErrors, see [As].
+func (e *BetterError) As(target any) bool {
+	if stack, ok := target.(*Stacktrace); ok && e.Stack != nil {
+		*stack = e.Stack
+		return true
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package betterr
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestAs(t *testing.T) {
+	pathErr := &os.PathError{Op: "open", Path: "config.yaml", Err: os.ErrNotExist}
+	err := Decorate(pathErr, "cannot load config")
+
+	var target *os.PathError
+	assertTrue(t, As(err, &target))
+	assertTrue(t, target == pathErr)
+
+	var betterErr *BetterError
+	assertTrue(t, As(err, &betterErr))
+	assertTrue(t, betterErr == err)
+
+	var stack Stacktrace
+	assertTrue(t, As(errors.Join(io.EOF, err), &stack))
+	assertTrue(t, stack == err.(*BetterError).Stack)
+	assertFalse(t, As(io.EOF, &stack))
+}
+
+func TestUnwrap(t *testing.T) {
+	err := Decorate(io.EOF, "read failed")
+	assertTrue(t, Unwrap(err) == io.EOF)
+	assertTrue(t, Unwrap(io.EOF) == nil)
+}
+
+func TestCause(t *testing.T) {
+	assertTrue(t, Cause(Decorate(Decorate(io.EOF, "a"), "b")) == io.EOF)
+	assertTrue(t, Cause(Decorate(errors.Join(io.EOF, io.ErrUnexpectedEOF), "b")) == io.EOF)
+	assertTrue(t, Cause(io.EOF) == io.EOF)
+	assertTrue(t, Cause(nil) == nil)
+}
+
+func TestOrigin(t *testing.T) {
+	origin := New("origin")
+	err := Decorate(Decorate(origin, "a"), "b")
+	assertTrue(t, Origin(err) == origin)
+	assertTrue(t, StackOf(err) == origin.(*BetterError).Stack)
+
+	// The deepest BetterError of the tree wins, even in the second branch of a join
+	joined := Decorate(errors.Join(New("shallow"), Decorate(origin, "deep")), "outer")
+	assertTrue(t, Origin(joined) == origin)
+
+	assertTrue(t, Origin(io.EOF) == nil)
+	assertTrue(t, StackOf(io.EOF) == nil)
+}
+
+func TestChain(t *testing.T) {
+	first, second := errors.New("first"), New("second")
+	join := errors.Join(first, second)
+	err := Decorate(join, "outer")
+	chain := Chain(err)
+	assertEqual(t, 4, len(chain))
+	assertTrue(t, chain[0] == err)
+	assertTrue(t, chain[1] == join)
+	assertTrue(t, chain[2] == first)
+	assertTrue(t, chain[3] == second)
+	assertEqual(t, 0, len(Chain(nil)))
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jjunac/betterr"
+)
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiDim    = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+)
+
+// Formats the error in Java style, colored for terminals.
+type colorFormatter struct{}
+
+var _ betterr.StreamFormatter = (*colorFormatter)(nil)
+
+func (f *colorFormatter) Format(err error) string {
+	var sb strings.Builder
+	for curr := err; curr != nil; {
+		if sb.Len() > 0 {
+			sb.WriteString(ansiYellow + "Caused by")
+			if service := remoteService(curr); service != "" {
+				sb.WriteString(" (remote service " + service + ")")
+			}
+			sb.WriteString(":" + ansiReset + " ")
+		}
+		r := betterr.Redact(curr)
+		sb.WriteString(ansiBold + ansiRed + r.Msg + ansiReset + "\n")
+		for _, frame := range r.Frames {
+			sb.WriteString("    " + ansiDim + "at" + ansiReset + " " + ansiCyan + frame.Function + ansiReset +
+				" " + ansiDim + "(" + frame.File + ":" + strconv.Itoa(frame.Line) + ")" + ansiReset + "\n")
+		}
+		betterErr, ok := curr.(*betterr.BetterError)
+		if !ok {
+			break
+		}
+		curr = betterErr.Wrapped
+	}
+	return sb.String()
+}
+
+func (f *colorFormatter) FormatTo(w io.Writer, err error) error {
+	_, writeErr := io.WriteString(w, f.Format(err))
+	return writeErr
+}
+
+// Formats the error like a Python traceback: the root cause first, and the frames from the outermost call to the innermost.
+// Example:
+//   Traceback (most recent call last):
+//     File "/src/myapp/file.go", line 100, in github.com/myapp.OtherFunction
+//   Error: something went wrong
+//
+//   The above exception was the direct cause of the following exception:
+//
+//   Traceback (most recent call last):
+//     File "/src/myapp/main.go", line 45, in github.com/myapp.main
+//     File "/src/myapp/file.go", line 123, in github.com/myapp.MyFunction
+//   Error: failed to process
+type pythonFormatter struct{}
+
+var _ betterr.StreamFormatter = (*pythonFormatter)(nil)
+
+func (f *pythonFormatter) Format(err error) string {
+	chain := betterr.Chain(err)
+	// Only the layers of the chain are printed, the errors of joins are part of the message of the join
+	for i, layer := range chain {
+		if _, ok := layer.(*betterr.BetterError); !ok {
+			chain = chain[:i+1]
+			break
+		}
+	}
+	var sb strings.Builder
+	for i := len(chain) - 1; i >= 0; i-- {
+		if i < len(chain)-1 {
+			sb.WriteString("\nThe above exception was the direct cause of the following exception:\n\n")
+		}
+		r := betterr.Redact(chain[i])
+		if len(r.Frames) > 0 {
+			sb.WriteString("Traceback (most recent call last):\n")
+			for j := len(r.Frames) - 1; j >= 0; j-- {
+				frame := r.Frames[j]
+				sb.WriteString(`  File "` + frame.File + `", line ` + strconv.Itoa(frame.Line) + ", in " + frame.Function + "\n")
+			}
+		}
+		sb.WriteString("Error: " + r.Msg + "\n")
+	}
+	return sb.String()
+}
+
+func (f *pythonFormatter) FormatTo(w io.Writer, err error) error {
+	_, writeErr := io.WriteString(w, f.Format(err))
+	return writeErr
+}
+
+// Returns the name of the service the error was received from, see betterr.RemoteServiceKey.
+func remoteService(err error) string {
+	betterErr, ok := err.(*betterr.BetterError)
+	if !ok {
+		return ""
+	}
+	for _, attr := range betterErr.Attrs {
+		if service, ok := attr.Value.(string); ok && attr.Key == betterr.RemoteServiceKey {
+			return service
+		}
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+// Command betterr makes the errors of BettErr logs readable.
+//
+// Usage:
+//   betterr [render] [flags] [files...]
+//   betterr top [flags] [files...]
+//
+// The render command reads log lines from the files, or from stdin, finds the errors formatted by
+// betterr.JsonFormatter or betterr.JavaStyleFormatter, and renders them again in the chosen format.
+// The other lines are printed as they are.
+//
+// The top command groups the errors of JSON logs by fingerprint, or by origin, and prints the most frequent groups
+// with their count, the timestamps of their first and last occurrences, and the trace of one of their errors.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `Usage:
+  betterr [render] [flags] [files...]   re-render the errors of the logs in a readable format
+  betterr top [flags] [files...]        rank the errors of JSON logs by number of occurrences
+
+Run "betterr <command> -h" for the flags of a command.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// Runs the command line and returns the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "render":
+			return runRender(args[1:], stdin, stdout, stderr)
+		case "top":
+			return runTop(args[1:], stdin, stdout, stderr)
+		case "help", "-h", "-help", "--help":
+			fmt.Fprint(stdout, usage)
+			return 0
+		}
+	}
+	return runRender(args, stdin, stdout, stderr)
+}
+
+// Calls fn with each input: the files, or stdin when there is none.
+func forEachInput(files []string, stdin io.Reader, fn func(name string, r io.Reader) error) error {
+	if len(files) == 0 {
+		return fn("stdin", stdin)
+	}
+	for _, name := range files {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		err = fn(name, file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jjunac/betterr"
+)
+
+// Flag holding a list of values, e.g. -trim /a -trim /b.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+var formatters = map[string]func() betterr.ErrorFormatter{
+	"color":  func() betterr.ErrorFormatter { return &colorFormatter{} },
+	"java":   func() betterr.ErrorFormatter { return &betterr.JavaStyleFormatter{} },
+	"go":     func() betterr.ErrorFormatter { return &betterr.GoStyleFormatter{} },
+	"python": func() betterr.ErrorFormatter { return &pythonFormatter{} },
+	"json":   func() betterr.ErrorFormatter { return &betterr.JsonFormatter{} },
+}
+
+// Returns the format used without -format: colors are for terminals, and are disabled by the NO_COLOR environment variable
+// (see https://no-color.org).
+func defaultFormat(stdout io.Writer) string {
+	if os.Getenv("NO_COLOR") != "" {
+		return "java"
+	}
+	if f, ok := stdout.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			return "color"
+		}
+	}
+	return "java"
+}
+
+// Options of the frames of the rendered errors.
+type frameFilter struct {
+	std       bool
+	exclude   *regexp.Regexp
+	maxFrames int
+}
+
+func runRender(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "", "output format: color, java, go, python or json (default color on a terminal without NO_COLOR, java otherwise)")
+	var trim stringList
+	flags.Var(&trim, "trim", "prefix to trim from the file paths, can be repeated (the module cache is always trimmed)")
+	std := flags.Bool("std", true, "show the frames of the standard library")
+	exclude := flags.String("exclude", "", "hide the frames whose function matches this regexp")
+	maxFrames := flags.Int("max-frames", 0, "maximum number of frames per error, 0 for all")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *format == "" {
+		*format = defaultFormat(stdout)
+	}
+	newFormatter, ok := formatters[*format]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown format %q\n", *format)
+		return 2
+	}
+	filter := frameFilter{std: *std, maxFrames: *maxFrames}
+	if *exclude != "" {
+		var err error
+		if filter.exclude, err = regexp.Compile(*exclude); err != nil {
+			fmt.Fprintf(stderr, "invalid -exclude: %v\n", err)
+			return 2
+		}
+	}
+	betterr.Redactors = append(betterr.Redactors, betterr.NewTrimPathRedactor(trim...))
+
+	r := &renderer{formatter: newFormatter(), filter: filter, out: bufio.NewWriter(stdout)}
+	err := forEachInput(flags.Args(), stdin, func(_ string, in io.Reader) error {
+		return r.render(in)
+	})
+	if flushErr := r.out.Flush(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "betterr:", err)
+		return 1
+	}
+	return 0
+}
+
+type renderer struct {
+	formatter betterr.ErrorFormatter
+	filter    frameFilter
+	out       *bufio.Writer
+	// Lines of the Java style trace being read, the first one is its message
+	javaTrace []string
+}
+
+func (r *renderer) render(in io.Reader) error {
+	reader := bufio.NewReader(in)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			r.renderLine(strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"))
+		}
+		if err == io.EOF {
+			r.flushJavaTrace()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (r *renderer) renderLine(line string) {
+	if len(r.javaTrace) > 0 && isJavaTraceLine(line) {
+		r.javaTrace = append(r.javaTrace, line)
+		return
+	}
+	r.flushJavaTrace()
+	if found, ok := findJSONError(line); ok {
+		if context := found.context(); context != "" {
+			r.out.WriteString(context)
+			r.out.WriteByte('\n')
+		}
+		r.writeError(found.err, found.fingerprint)
+		return
+	}
+	// The line may be the message of a Java style trace, which is known when the next line is read
+	r.javaTrace = []string{line}
+}
+
+// Frames and causes of the Java style, as written by betterr.JavaStyleFormatter.
+func isJavaTraceLine(line string) bool {
+	return strings.HasPrefix(line, "    at ") || strings.HasPrefix(line, "Caused by: ") || strings.HasPrefix(line, "Caused by (remote service ")
+}
+
+func (r *renderer) flushJavaTrace() {
+	trace := r.javaTrace
+	r.javaTrace = nil
+	if len(trace) == 0 {
+		return
+	}
+	if len(trace) > 1 {
+		// Layers are followed by a newline in the Java style, unlike the plain Go error ending the chain
+		text := strings.Join(trace, "\n")
+		if strings.HasPrefix(trace[len(trace)-1], "    at ") {
+			text += "\n"
+		}
+		if err, parseErr := betterr.ParseJavaStyle(text); parseErr == nil {
+			r.writeError(err, "")
+			return
+		}
+	}
+	for _, line := range trace {
+		r.out.WriteString(line)
+		r.out.WriteByte('\n')
+	}
+}
+
+// Writes the error, with the fingerprint of the log when it has one.
+func (r *renderer) writeError(err error, fingerprint string) {
+	err = r.filter.apply(err)
+	output := r.formatter.Format(err)
+	if _, ok := r.formatter.(*betterr.JsonFormatter); ok && fingerprint != "" {
+		output = replaceFingerprint(output, betterr.Fingerprint(err), fingerprint)
+	}
+	r.out.WriteString(output)
+	if !strings.HasSuffix(output, "\n") {
+		r.out.WriteByte('\n')
+	}
+}
+
+// Replaces the fingerprint computed by betterr.JsonFormatter with the one of the log:
+// the parsed chain lacks the templates and the frames it was computed from, so they usually differ.
+func replaceFingerprint(output, computed, fingerprint string) string {
+	computedJSON, _ := json.Marshal(computed)
+	fingerprintJSON, _ := json.Marshal(fingerprint)
+	prefix := `{"fingerprint":`
+	if !strings.HasPrefix(output, prefix+string(computedJSON)) {
+		return output
+	}
+	return prefix + string(fingerprintJSON) + output[len(prefix)+len(computedJSON):]
+}
+
+// Returns a copy of the chain with the frames filtered, the errors of the joins included.
+func (f frameFilter) apply(err error) error {
+	if join, ok := err.(interface{ Unwrap() []error }); ok {
+		var errs []error
+		for _, joined := range join.Unwrap() {
+			errs = append(errs, f.apply(joined))
+		}
+		return errors.Join(errs...)
+	}
+	betterErr, ok := err.(*betterr.BetterError)
+	if !ok {
+		return err
+	}
+	var frames []betterr.StackFrames
+	for _, frame := range betterErr.Stack.GetFrames() {
+		if f.maxFrames > 0 && len(frames) == f.maxFrames {
+			break
+		}
+		if (!f.std && frame.IsStd()) || (f.exclude != nil && f.exclude.MatchString(frame.Function)) {
+			continue
+		}
+		frames = append(frames, frame)
+	}
+	cp := *betterErr
+	cp.Stack = &betterr.StaticStacktrace{Frames: frames}
+	cp.Wrapped = f.apply(betterErr.Wrapped)
+	return &cp
+}
+
+// Error found in a JSON log line.
+type logError struct {
+	err error
+	// Fingerprint written by betterr.JsonFormatter, empty for errors in the Java style
+	fingerprint string
+	// Fields of the JSON log without the error, nil when the error is the whole JSON object
+	fields map[string]any
+	// Text around the JSON object
+	prefix, suffix string
+}
+
+// Returns the rest of the line, without the error.
+func (l *logError) context() string {
+	if l.fields == nil {
+		return strings.TrimSpace(l.prefix + l.suffix)
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if encoder.Encode(l.fields) != nil {
+		return strings.TrimSpace(l.prefix + l.suffix)
+	}
+	return l.prefix + strings.TrimSuffix(buf.String(), "\n") + l.suffix
+}
+
+// Finds an error formatted by betterr.JsonFormatter in a log line, either the whole line or a field of a JSON log.
+func findJSONError(line string) (*logError, bool) {
+	start := strings.IndexByte(line, '{')
+	if start < 0 {
+		return nil, false
+	}
+	decoder := json.NewDecoder(strings.NewReader(line[start:]))
+	decoder.UseNumber()
+	var root any
+	if decoder.Decode(&root) != nil {
+		return nil, false
+	}
+	found := &logError{prefix: line[:start], suffix: line[start+int(decoder.InputOffset()):]}
+	if isJSONError(root) {
+		return found, found.parse(root)
+	}
+	object, isObject := root.(map[string]any)
+	if !isObject {
+		return nil, false
+	}
+	for _, key := range sortedKeys(object) {
+		if found.parse(object[key]) {
+			delete(object, key)
+			found.fields = object
+			return found, true
+		}
+	}
+	return nil, false
+}
+
+// Parses a value holding an error: an error object, or a string holding a JSON or Java style error.
+func (l *logError) parse(value any) bool {
+	if s, ok := value.(string); ok {
+		if strings.HasPrefix(s, "{") {
+			decoder := json.NewDecoder(strings.NewReader(s))
+			decoder.UseNumber()
+			if decoder.Decode(&value) != nil {
+				return false
+			}
+		} else if strings.Contains(s, "\n    at ") {
+			parsed, parseErr := betterr.ParseJavaStyle(s)
+			if parseErr != nil {
+				return false
+			}
+			l.err = parsed
+			return true
+		}
+	}
+	if !isJSONError(value) {
+		return false
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	parsed, err := betterr.ParseJSON(data)
+	if err != nil {
+		return false
+	}
+	l.err = parsed
+	l.fingerprint, _ = value.(map[string]any)["fingerprint"].(string)
+	return true
+}
+
+// Objects produced by betterr.JsonFormatter have a message and at least a stack, a cause or a fingerprint.
+func isJSONError(value any) bool {
+	object, ok := value.(map[string]any)
+	if !ok {
+		return false
+	}
+	if _, ok := object["message"].(string); !ok {
+		return false
+	}
+	_, hasStack := object["stack"]
+	_, hasCause := object["cause"]
+	_, hasFingerprint := object["fingerprint"]
+	return hasStack || hasCause || hasFingerprint
+}
+
+func sortedKeys(object map[string]any) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
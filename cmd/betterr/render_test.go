@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jjunac/betterr"
+	"github.com/jjunac/betterr/betterrtest"
+)
+
+func init() {
+	flag.BoolVar(&betterrtest.Update, "update", betterrtest.Update, "write the golden files instead of comparing them")
+}
+
+func sampleError() error {
+	return &betterr.BetterError{
+		Msg: "cannot load user",
+		Stack: &betterr.StaticStacktrace{Frames: []betterr.StackFrames{
+			{Function: "github.com/myapp.(*Handler).ServeHTTP", File: "/build/myapp/handler.go", Line: 42},
+			{Function: "net/http.serverHandler.ServeHTTP", File: "/usr/local/go/src/net/http/server.go", Line: 2938},
+		}},
+		Attrs: []betterr.Attr{{Key: "user", Value: "42"}},
+		Wrapped: &betterr.BetterError{
+			Msg: "query failed",
+			Stack: &betterr.StaticStacktrace{Frames: []betterr.StackFrames{
+				{Function: "github.com/lib/pq.(*conn).query", File: "/root/go/pkg/mod/github.com/lib/pq@v1.10.0/conn.go", Line: 12},
+				{Function: "github.com/myapp.(*Repo).Find", File: "/build/myapp/repo.go", Line: 7},
+			}},
+			Wrapped: errors.New("connection refused"),
+		},
+	}
+}
+
+// Log mixing plain lines, JSON logs holding errors and Java style traces.
+func sampleLog() string {
+	err := sampleError()
+	json := new(betterr.JsonFormatter).Format(err)
+	java := new(betterr.JavaStyleFormatter).Format(err)
+	quotedJava := strings.ReplaceAll(strings.ReplaceAll(java, "\n", `\n`), `"`, `\"`)
+	return strings.Join([]string{
+		"server started",
+		`{"level":"error","time":"2024-05-01T10:00:00Z","msg":"request failed","error":` + json + `}`,
+		json,
+		"2024-05-01 10:00:01 ERROR " + java,
+		`{"level":"error","msg":"request failed","error":"` + quotedJava + `"}`,
+		"server stopped",
+	}, "\n") + "\n"
+}
+
+func runCommand(t *testing.T, stdin string, args ...string) string {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	if code := run(args, strings.NewReader(stdin), &stdout, &stderr); code != 0 {
+		t.Fatalf("\nExit code %d: %s", code, stderr.String())
+	}
+	return stdout.String()
+}
+
+func withRedactors(t *testing.T) {
+	previous := betterr.Redactors
+	t.Cleanup(func() {
+		betterr.Redactors = previous
+	})
+}
+
+func TestRender(t *testing.T) {
+	for _, format := range []string{"color", "java", "go", "python", "json"} {
+		t.Run(format, func(t *testing.T) {
+			withRedactors(t)
+			betterrtest.AssertSnapshot(t, runCommand(t, sampleLog(), "render", "-format", format))
+		})
+	}
+}
+
+func TestRender_Frames(t *testing.T) {
+	withRedactors(t)
+	output := runCommand(t, new(betterr.JavaStyleFormatter).Format(sampleError()),
+		"-format", "java", "-std=false", "-exclude", `^github\.com/lib/`, "-trim", "/build/myapp/", "-max-frames", "1")
+	expected := "cannot load user\n" +
+		"    at github.com/myapp.(*Handler).ServeHTTP (handler.go:42)\n" +
+		"Caused by: query failed\n" +
+		"    at github.com/myapp.(*Repo).Find (repo.go:7)\n" +
+		"Caused by: connection refused\n"
+	if output != expected {
+		t.Errorf("\nExpected:\n%s\nActual:\n%s", expected, output)
+	}
+}
+
+func TestRender_Files(t *testing.T) {
+	withRedactors(t)
+	file := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(file, []byte(new(betterr.JsonFormatter).Format(sampleError())+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	output := runCommand(t, "", "-format", "go", file, file)
+	if output != strings.Repeat("cannot load user: query failed: connection refused\n", 2) {
+		t.Errorf("\nUnexpected output:\n%s", output)
+	}
+}
+
+func TestRender_KeepsFingerprint(t *testing.T) {
+	withRedactors(t)
+	// Fingerprint computed by the service, from the templates and frames the log doesn't have
+	log := strings.Replace(new(betterr.JsonFormatter).Format(sampleError()), betterr.Fingerprint(sampleError()), "0123456789abcdef", 1)
+	output := runCommand(t, log, "-format", "json")
+	if !strings.HasPrefix(output, `{"fingerprint":"0123456789abcdef","message":"cannot load user"`) {
+		t.Errorf("\nExpected the fingerprint of the log, got:\n%s", output)
+	}
+}
+
+func TestRender_DefaultFormat(t *testing.T) {
+	if output, java := runCommand(t, sampleLog()), runCommand(t, sampleLog(), "-format", "java"); output != java {
+		t.Errorf("\nExpected the Java style out of a terminal, got:\n%s", output)
+	}
+	file, err := os.Create(filepath.Join(t.TempDir(), "out.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if format := defaultFormat(file); format != "java" {
+		t.Errorf("\nExpected java for a file, got %s", format)
+	}
+	t.Setenv("NO_COLOR", "1")
+	if format := defaultFormat(os.Stdout); format != "java" {
+		t.Errorf("\nExpected java with NO_COLOR, got %s", format)
+	}
+}
+
+func TestRender_InvalidFlags(t *testing.T) {
+	for _, args := range [][]string{{"-format", "xml"}, {"-exclude", "("}, {"-unknown"}, {"missing.log"}} {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, strings.NewReader(""), &stdout, &stderr); code == 0 {
+			t.Errorf("\nExpected %v to fail", args)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jjunac/betterr"
+)
+
+// Fields of the JSON logs holding their timestamp, by order of preference.
+var timestampKeys = []string{"time", "timestamp", "ts", "@timestamp"}
+
+// Errors sharing the same key.
+type errorGroup struct {
+	key   string
+	count int
+	// Timestamps of the first and last occurrences, zero when the logs have none
+	first, last time.Time
+	// The first occurrence of the group
+	sample error
+	// Position of the first occurrence in the logs, to rank the groups of same count
+	index int
+}
+
+func runTop(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("top", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	by := flags.String("by", "fingerprint", "grouping of the errors: fingerprint (message templates and origin frames) or origin (origin function)")
+	limit := flags.Int("n", 10, "number of groups to print, 0 for all")
+	format := flags.String("format", "java", "format of the representative trace: color, java, go, python or json")
+	maxFrames := flags.Int("max-frames", 0, "maximum number of frames per error of the trace, 0 for all")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *by != "fingerprint" && *by != "origin" {
+		fmt.Fprintf(stderr, "unknown grouping %q\n", *by)
+		return 2
+	}
+	newFormatter, ok := formatters[*format]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown format %q\n", *format)
+		return 2
+	}
+
+	groups := map[string]*errorGroup{}
+	err := forEachInput(flags.Args(), stdin, func(_ string, in io.Reader) error {
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(nil, 16*1024*1024)
+		for scanner.Scan() {
+			found, ok := findJSONError(scanner.Text())
+			if !ok {
+				continue
+			}
+			key := found.fingerprint
+			if *by == "origin" {
+				key = originOf(found.err)
+			} else if key == "" {
+				key = betterr.Fingerprint(found.err)
+			}
+			group := groups[key]
+			if group == nil {
+				group = &errorGroup{key: key, sample: found.err, index: len(groups)}
+				groups[key] = group
+			}
+			group.add(timestampOf(found.fields))
+		}
+		return scanner.Err()
+	})
+	if err != nil {
+		fmt.Fprintln(stderr, "betterr:", err)
+		return 1
+	}
+
+	ranked := make([]*errorGroup, 0, len(groups))
+	for _, group := range groups {
+		ranked = append(ranked, group)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].count != ranked[j].count {
+			return ranked[i].count > ranked[j].count
+		}
+		return ranked[i].index < ranked[j].index
+	})
+	if *limit > 0 && len(ranked) > *limit {
+		ranked = ranked[:*limit]
+	}
+
+	out := bufio.NewWriter(stdout)
+	formatter := newFormatter()
+	filter := frameFilter{std: true, maxFrames: *maxFrames}
+	for i, group := range ranked {
+		if i > 0 {
+			out.WriteByte('\n')
+		}
+		noun := "errors"
+		if group.count == 1 {
+			noun = "error"
+		}
+		fmt.Fprintf(out, "#%d  %d %s  %s: %s\n", i+1, group.count, noun, *by, group.key)
+		fmt.Fprintf(out, "first seen: %s  last seen: %s\n", formatTimestamp(group.first), formatTimestamp(group.last))
+		if *by == "fingerprint" {
+			fmt.Fprintf(out, "origin: %s\n", originOf(group.sample))
+		}
+		trace := formatter.Format(filter.apply(group.sample))
+		out.WriteString(trace)
+		if !strings.HasSuffix(trace, "\n") {
+			out.WriteByte('\n')
+		}
+	}
+	if err := out.Flush(); err != nil {
+		fmt.Fprintln(stderr, "betterr:", err)
+		return 1
+	}
+	return 0
+}
+
+func (g *errorGroup) add(timestamp time.Time) {
+	g.count++
+	if timestamp.IsZero() {
+		return
+	}
+	if g.first.IsZero() || timestamp.Before(g.first) {
+		g.first = timestamp
+	}
+	if g.last.IsZero() || timestamp.After(g.last) {
+		g.last = timestamp
+	}
+}
+
+// Returns the innermost frame of the origin of the error, see betterr.Origin.
+func originOf(err error) string {
+	stack := betterr.StackOf(err)
+	if stack == nil || len(stack.GetFrames()) == 0 {
+		return "unknown"
+	}
+	return stack.GetFrames()[0].Function
+}
+
+// Returns the timestamp of a JSON log: a RFC 3339 string, or a number of seconds since the Unix epoch (milliseconds for
+// the large ones), as written by slog, zap, zerolog and logrus.
+func timestampOf(fields map[string]any) time.Time {
+	for _, key := range timestampKeys {
+		switch v := fields[key].(type) {
+		case string:
+			if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				return t
+			}
+		case json.Number:
+			seconds, err := strconv.ParseFloat(string(v), 64)
+			if err != nil {
+				continue
+			}
+			if seconds > 1e11 {
+				seconds /= 1000
+			}
+			whole, frac := math.Modf(seconds)
+			return time.Unix(int64(whole), int64(frac*1e9)).UTC()
+		}
+	}
+	return time.Time{}
+}
+
+func formatTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jjunac/betterr"
+	"github.com/jjunac/betterr/betterrtest"
+)
+
+func userNotFound(id string) error {
+	return &betterr.BetterError{
+		Template: "user %s not found",
+		Args:     []any{id},
+		Stack:    betterrtest.FakeStacktrace("github.com/myapp.(*Repo).Find", "github.com/myapp.(*Handler).ServeHTTP"),
+	}
+}
+
+// JSON logs of slog, zap and a raw JSON error, with 3 errors of the same fingerprint but with different messages.
+func topLog() string {
+	json := new(betterr.JsonFormatter)
+	return strings.Join([]string{
+		`{"time":"2024-05-01T10:02:00Z","level":"ERROR","msg":"request failed","error":` + json.Format(userNotFound("42")) + `}`,
+		"server started",
+		`{"ts":1714557600.5,"level":"error","msg":"request failed","error":` + json.Format(sampleError()) + `}`,
+		`{"time":"2024-05-01T10:00:00Z","level":"ERROR","msg":"request failed","error":` + json.Format(userNotFound("7")) + `}`,
+		json.Format(userNotFound("1")),
+	}, "\n") + "\n"
+}
+
+func TestTop(t *testing.T) {
+	for _, by := range []string{"fingerprint", "origin"} {
+		t.Run(by, func(t *testing.T) {
+			betterrtest.AssertSnapshot(t, runCommand(t, topLog(), "top", "-by", by, "-format", "go"))
+		})
+	}
+}
+
+func TestTop_Limit(t *testing.T) {
+	output := runCommand(t, topLog(), "top", "-n", "1", "-max-frames", "1")
+	expected := "#1  3 errors  fingerprint: " + betterr.Fingerprint(userNotFound("42")) + "\n" +
+		"first seen: 2024-05-01T10:00:00Z  last seen: 2024-05-01T10:02:00Z\n" +
+		"origin: github.com/myapp.(*Repo).Find\n" +
+		"user 42 not found\n" +
+		"    at github.com/myapp.(*Repo).Find (/src/github.com/myapp/myapp.go:10)\n"
+	if output != expected {
+		t.Errorf("\nExpected:\n%s\nActual:\n%s", expected, output)
+	}
+}
+
+func TestTop_InvalidFlags(t *testing.T) {
+	for _, args := range [][]string{{"top", "-by", "message"}, {"top", "-format", "xml"}, {"top", "missing.log"}} {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, strings.NewReader(""), &stdout, &stderr); code == 0 {
+			t.Errorf("\nExpected %v to fail", args)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package betterr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sets the message and the causes of the BetterError of [Errorf] and [Decoratef], recognizing the %w verbs like fmt.Errorf does.
+// The errors of the %w verbs are wrapped by the BetterError and removed from its message,
+// so the formatters don't print them twice: a trailing ": %w" is removed from the template,
+// and the other %w verbs print nothing.
+func setFormatted(err *BetterError, wrapped error, format string, args []any) {
+	err.Template, err.Args, err.Wrapped = format, args, wrapped
+	if !hasErrorArg(args) {
+		// Without errors, the %w verbs have nothing to wrap, and the format string doesn't need to be parsed
+		return
+	}
+	verbs := parseVerbs(format)
+	var causes []error
+	var template strings.Builder
+	last := 0
+	explicitIndex := false
+	for _, verb := range verbs {
+		explicitIndex = explicitIndex || verb.explicitIndex
+		if verb.verb != 'w' || verb.arg >= len(args) {
+			continue
+		}
+		cause, ok := args[verb.arg].(error)
+		if !ok || cause == nil {
+			// Let fmt report the bad verb, as fmt.Errorf does
+			continue
+		}
+		if len(causes) == 0 {
+			err.Args = append([]any(nil), args...)
+		}
+		causes = append(causes, cause)
+		err.Args[verb.arg] = omittedArg{}
+		template.WriteString(format[last : verb.end-1])
+		template.WriteByte('v')
+		last = verb.end
+	}
+	if len(causes) == 0 {
+		return
+	}
+	template.WriteString(format[last:])
+	err.Template = template.String()
+
+	// The usual "message: %w" becomes "message", as the formatters print the causes after the message
+	for i := len(verbs) - 1; i >= 0 && !explicitIndex; i-- {
+		verb := verbs[i]
+		if verb.end != len(err.Template) || verb.arg != len(err.Args)-1 || err.Args[verb.arg] != (omittedArg{}) {
+			break
+		}
+		err.Template = strings.TrimRight(err.Template[:verb.start], " :;,-")
+		err.Args = err.Args[:verb.arg]
+	}
+	if wrapped != nil {
+		causes = append([]error{wrapped}, causes...)
+	}
+	if len(causes) == 1 {
+		err.Wrapped = causes[0]
+	} else {
+		err.Wrapped = errors.Join(causes...)
+	}
+}
+
+func hasErrorArg(args []any) bool {
+	for _, arg := range args {
+		if _, ok := arg.(error); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Replaces the arguments of the %w verbs, whose errors are printed as causes rather than in the message.
+type omittedArg struct{}
+
+func (omittedArg) Format(fmt.State, rune) {}
+
+func (omittedArg) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+// A verb of a format string, from its % to its verb character included.
+type formatVerb struct {
+	start, end    int
+	verb          byte
+	arg           int
+	explicitIndex bool
+}
+
+// Returns the verbs of a format string and the index of the argument each one consumes, following the rules of fmt,
+// including explicit argument indexes ("%[2]d") and the arguments consumed by '*' widths and precisions.
+func parseVerbs(format string) []formatVerb {
+	var verbs []formatVerb
+	arg := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		verb := formatVerb{start: i}
+		i++
+		// Flags
+		for i < len(format) && strings.IndexByte("+-# 0", format[i]) >= 0 {
+			i++
+		}
+		// Argument index, width, precision and argument index of the verb
+		for i < len(format) {
+			switch c := format[i]; {
+			case c == '[':
+				end := strings.IndexByte(format[i:], ']')
+				if end < 0 {
+					i = len(format)
+					continue
+				}
+				if index, ok := parseArgIndex(format[i+1 : i+end]); ok {
+					arg = index
+					verb.explicitIndex = true
+				}
+				i += end + 1
+				continue
+			case c == '*':
+				arg++
+			case c == '.' || (c >= '0' && c <= '9'):
+			default:
+				goto verbChar
+			}
+			i++
+		}
+	verbChar:
+		if i >= len(format) {
+			break
+		}
+		if format[i] == '%' && i == verb.start+1 {
+			continue
+		}
+		verb.verb = format[i]
+		verb.arg = arg
+		verb.end = i + 1
+		verbs = append(verbs, verb)
+		arg++
+	}
+	return verbs
+}
+
+// Parses the content of an explicit argument index, which is 1-based in fmt.
+func parseArgIndex(s string) (int, bool) {
+	if !isNumber(s) {
+		return 0, false
+	}
+	index := 0
+	for _, c := range s {
+		index = index*10 + int(c-'0')
+	}
+	if index < 1 {
+		return 0, false
+	}
+	return index - 1, true
+}
@@ -0,0 +1,80 @@
+package betterr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestErrorf_WrapVerb(t *testing.T) {
+	cause := errors.New("no such file")
+	err := Errorf("cannot load %s: %w", "config.yaml", cause).(*BetterError)
+	assertEqual(t, "cannot load config.yaml", err.Message())
+	assertEqual(t, "cannot load %s", err.Template)
+	assertEqual(t, 1, len(err.Args))
+	assertTrue(t, err.Wrapped == cause)
+	assertTrue(t, errors.Is(err, cause))
+	assertEqual(t, "cannot load config.yaml: no such file", new(GoStyleFormatter).Format(err))
+}
+
+func TestErrorf_WrapVerbInTheMiddle(t *testing.T) {
+	err := Errorf("read (%w) at offset %d", io.ErrUnexpectedEOF, 42).(*BetterError)
+	assertEqual(t, "read () at offset 42", err.Message())
+	assertTrue(t, err.Wrapped == io.ErrUnexpectedEOF)
+	mocked := &BetterError{Template: err.Template, Args: err.Args, Stack: &mockedStacktrace{}}
+	assertJSONEq(t,
+		`{"fingerprint": "`+Fingerprint(mocked)+`", "message": "read () at offset 42", "template": "read (%v) at offset %d", "args": [null, 42]}`,
+		new(JsonFormatter).Format(mocked))
+}
+
+func TestErrorf_MultipleWrapVerbs(t *testing.T) {
+	first, second := errors.New("first"), errors.New("second")
+	err := Errorf("both failed: %w; %w", first, second).(*BetterError)
+	assertEqual(t, "both failed", err.Message())
+	assertTrue(t, errors.Is(err, first))
+	assertTrue(t, errors.Is(err, second))
+
+	err = Errorf("%w, %w", first, second).(*BetterError)
+	assertEqual(t, "", err.Message())
+	assertTrue(t, errors.Is(err, first) && errors.Is(err, second))
+}
+
+func TestErrorf_BadWrapVerb(t *testing.T) {
+	// Same output as fmt.Errorf
+	err := Errorf("not an error: %w", "oops").(*BetterError)
+	assertEqual(t, "not an error: %!w(string=oops)", err.Message())
+	assertTrue(t, err.Wrapped == nil)
+	assertEqual(t, "no verb %!w(<nil>)", Errorf("no verb %w", nil).(*BetterError).Message())
+}
+
+func TestErrorf_ExplicitArgIndex(t *testing.T) {
+	cause := errors.New("cause")
+	err := Errorf("%[2]s failed: %[1]w", cause, "job").(*BetterError)
+	assertEqual(t, "job failed: ", err.Message())
+	assertTrue(t, err.Wrapped == cause)
+}
+
+func TestDecoratef_WrapVerb(t *testing.T) {
+	decorated, other := errors.New("decorated"), errors.New("other")
+	err := Decoratef(decorated, "retry failed: %w", other).(*BetterError)
+	assertEqual(t, "retry failed", err.Message())
+	assertTrue(t, errors.Is(err, decorated))
+	assertTrue(t, errors.Is(err, other))
+
+	err = Decoratef(decorated, "%d%% done", 50).(*BetterError)
+	assertEqual(t, "50% done", err.Message())
+	assertTrue(t, err.Wrapped == decorated)
+}
+
+func TestParseVerbs(t *testing.T) {
+	verbs := parseVerbs("%d %% %-5.2f %*d %[1]w %.*s%")
+	var found []string
+	var args []int
+	for _, verb := range verbs {
+		found = append(found, string(verb.verb))
+		args = append(args, verb.arg)
+	}
+	assertEqual(t, "[d f d w s]", fmt.Sprint(found))
+	assertEqual(t, "[0 1 3 0 2]", fmt.Sprint(args))
+}
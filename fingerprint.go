@@ -0,0 +1,93 @@
+package betterr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+)
+
+// DefaultFingerprinter is used by [Fingerprint] and by the formatters outputting fingerprints.
+var DefaultFingerprinter = &Fingerprinter{}
+
+// Returns a stable identifier of the kind of the error, to group and deduplicate errors in alerts and dashboards.
+// It uses the [DefaultFingerprinter], see [Fingerprinter.Fingerprint].
+func Fingerprint(err error) string {
+	return DefaultFingerprinter.Fingerprint(err)
+}
+
+// Computes the fingerprints of errors.
+// The zero value is ready to use and ignores line numbers.
+type Fingerprinter struct {
+	// IncludeLines adds the lines of the origin frames to the fingerprint.
+	// Errors then change fingerprint whenever the code above them changes.
+	IncludeLines bool
+	// Frames is the number of frames of the origin error taken into account (default 1).
+	Frames int
+}
+
+// Values replaced in the messages before hashing them: quoted strings and words containing digits (numbers, IDs, addresses...).
+var messageValues = regexp.MustCompile("\"(?:[^\"\\\\]|\\\\.)*\"|'(?:[^'\\\\]|\\\\.)*'|`[^`]*`|[\\w.:-]*\\d(?:[\\w.:-]*\\w)?")
+
+// Returns a hash of the messages of the chain, normalized by removing the values they contain
+// (or their format string for errors created by [Errorf] and [Decoratef]),
+// and of the origin frames, i.e. the top frames of the deepest BetterError of the chain,
+// or of the error of another library ending it when the [StackExtractors] find its stack trace.
+// Two errors created at the same place with different values get the same fingerprint.
+// Returns an empty string for a nil error.
+func (fp *Fingerprinter) Fingerprint(err error) string {
+	if err == nil {
+		return ""
+	}
+	hash := sha256.New()
+	var stack Stacktrace
+	for curr := err; curr != nil; {
+		betterErr, ok := curr.(*BetterError)
+		if !ok {
+			msg := curr.Error()
+			if joinMembers(curr) != nil {
+				// The message of a join holds the stack traces of its BetterErrors, whose paths depend on the machine
+				msg = new(GoStyleFormatter).Format(curr)
+			}
+			hash.Write([]byte(normalizeMessage(msg)))
+			hash.Write([]byte{0})
+			// The errors of other libraries may have the stack of the origin
+			if foreign := foreignStack(curr); foreign != nil {
+				stack = foreign
+			}
+			break
+		}
+		stack = betterErr.Stack
+		// The format string of Errorf and Decoratef is already free of values
+		template := betterErr.Template
+		if template == "" {
+			template = normalizeMessage(betterErr.Msg)
+		}
+		hash.Write([]byte(template))
+		hash.Write([]byte{0})
+		curr = betterErr.Wrapped
+	}
+	if stack != nil {
+		frames := stack.GetFrames()
+		n := fp.Frames
+		if n <= 0 {
+			n = 1
+		}
+		if n > len(frames) {
+			n = len(frames)
+		}
+		for _, frame := range frames[:n] {
+			hash.Write([]byte(frame.Function))
+			if fp.IncludeLines {
+				hash.Write([]byte(":" + strconv.Itoa(frame.Line)))
+			}
+			hash.Write([]byte{0})
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:8])
+}
+
+// Replaces the values of the message by a placeholder, e.g. `user "bob" not found (id 42)` gives `user * not found (id *)`.
+func normalizeMessage(msg string) string {
+	return messageValues.ReplaceAllString(msg, "*")
+}
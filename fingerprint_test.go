@@ -0,0 +1,73 @@
+package betterr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func notFound(id int) error {
+	return Errorf("user %d not found", id)
+}
+
+func TestFingerprint(t *testing.T) {
+	// Same origin, different values
+	assertEqual(t, Fingerprint(notFound(1)), Fingerprint(notFound(42)))
+	assertEqual(t,
+		Fingerprint(Decoratef(notFound(1), "request %q failed", "a")),
+		Fingerprint(Decoratef(notFound(2), "request %q failed", "b")))
+	// Different origins
+	assertFalse(t, Fingerprint(notFound(1)) == Fingerprint(Errorf("user %d not found", 1)))
+	// Different messages
+	assertFalse(t, Fingerprint(Decorate(notFound(1), "a")) == Fingerprint(Decorate(notFound(1), "b")))
+	assertFalse(t, Fingerprint(errors.New("a")) == Fingerprint(errors.New("b")))
+
+	assertEqual(t, "", Fingerprint(nil))
+	assertEqual(t, 16, len(Fingerprint(notFound(1))))
+}
+
+func TestFingerprinter_IncludeLines(t *testing.T) {
+	withLine := func(line int) error {
+		return &BetterError{Msg: "failed", Stack: &StaticStacktrace{Frames: []StackFrames{{Function: "github.com/myapp.F", Line: line}}}}
+	}
+	assertEqual(t, Fingerprint(withLine(1)), Fingerprint(withLine(2)))
+	fp := &Fingerprinter{IncludeLines: true}
+	assertFalse(t, fp.Fingerprint(withLine(1)) == fp.Fingerprint(withLine(2)))
+}
+
+func TestFingerprinter_Frames(t *testing.T) {
+	withCaller := func(caller string) error {
+		return &BetterError{Msg: "failed", Stack: &StaticStacktrace{Frames: []StackFrames{{Function: "github.com/myapp.F"}, {Function: caller}}}}
+	}
+	assertEqual(t, Fingerprint(withCaller("a")), Fingerprint(withCaller("b")))
+	fp := &Fingerprinter{Frames: 2}
+	assertFalse(t, fp.Fingerprint(withCaller("a")) == fp.Fingerprint(withCaller("b")))
+}
+
+func TestFingerprint_JoinIndependentOfPaths(t *testing.T) {
+	joined := func(file string) error {
+		origin := &BetterError{Msg: "failed", Stack: &StaticStacktrace{Frames: []StackFrames{{Function: "github.com/myapp.F", File: file}}}}
+		return errors.Join(origin, errors.New("other"))
+	}
+	assertEqual(t, Fingerprint(joined("/home/ci/src/myapp/f.go")), Fingerprint(joined("/build/myapp/f.go")))
+}
+
+func TestNormalizeMessage(t *testing.T) {
+	testCases := map[string]string{
+		`user "bob" not found (id 42)`:          `user * not found (id *)`,
+		"dial tcp 10.0.0.1:5432: timeout":       "dial tcp *: timeout",
+		"request 7f3e-4b2a took 1.5s":           "request * took *",
+		"cannot open 'config.yaml'":             "cannot open *",
+		"no digits here":                        "no digits here",
+		"escaped \"quote \\\" inside\" is kept": "escaped * is kept",
+	}
+	for msg, expected := range testCases {
+		assertEqual(t, expected, normalizeMessage(msg))
+	}
+}
+
+func TestJsonFormatter_Fingerprint(t *testing.T) {
+	err := notFound(1)
+	assertTrue(t, strings.HasPrefix(new(JsonFormatter).Format(err), `{"fingerprint":"`+Fingerprint(err)+`","message":`))
+	assertEqual(t, `{"message":""}`, new(JsonFormatter).Format(nil))
+}
@@ -2,8 +2,12 @@ package betterr
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // Interface to format error in string.
@@ -12,38 +16,130 @@ import (
 // - [GoStyleFormatter]
 // - [JavaStyleFormatter]
 // - [JsonFormatter]
+// - [TemplateFormatter]
+// - [GCPErrorReportingFormatter]
+// - [SentryFormatter]
 type ErrorFormatter interface {
 	Format(err error) string
 }
 
+// Optional interface of the formatters able to write the formatted error to an io.Writer,
+// without building the whole string in memory. All the formatters of the library implement it.
+// Use [FormatTo] to stream the output of any [ErrorFormatter].
+type StreamFormatter interface {
+	ErrorFormatter
+	// Formatters issue many small writes, wrap w in a bufio.Writer if writes are expensive.
+	FormatTo(w io.Writer, err error) error
+}
+
+// Writes the error formatted by f to w, streaming it if f is a [StreamFormatter].
+// Other formatters build the whole string with Format before writing it.
+func FormatTo(w io.Writer, f ErrorFormatter, err error) error {
+	if sf, ok := f.(StreamFormatter); ok {
+		return sf.FormatTo(w, err)
+	}
+	_, writeErr := io.WriteString(w, f.Format(err))
+	return writeErr
+}
+
 // DefaultFortmatter is the default formatter used by BetterError.Error().
 // You can change the default formatter by setting this variable.
 // By default, it uses [JavaStyleFormatter].
 var DefaultFortmatter ErrorFormatter = &JavaStyleFormatter{}
 
+// Implements [ErrorFormatter.Format] on top of [StreamFormatter.FormatTo].
+func formatString(f StreamFormatter, err error) string {
+	sb := strings.Builder{}
+	// Writing to a strings.Builder never fails
+	_ = f.FormatTo(&sb, err)
+	return sb.String()
+}
+
+// Writer keeping the first error, so formatters don't have to check every write.
+// It also counts the bytes written, to know if something has already been written.
+type formatWriter struct {
+	w   io.Writer
+	n   int
+	err error
+	buf [20]byte
+}
+
+func newFormatWriter(w io.Writer) *formatWriter {
+	return &formatWriter{w: w}
+}
+
+func (fw *formatWriter) write(p []byte) {
+	if fw.err != nil {
+		return
+	}
+	var n int
+	n, fw.err = fw.w.Write(p)
+	fw.n += n
+}
+
+func (fw *formatWriter) writeString(s string) {
+	if fw.err != nil {
+		return
+	}
+	var n int
+	n, fw.err = io.WriteString(fw.w, s)
+	fw.n += n
+}
+
+func (fw *formatWriter) writeByte(b byte) {
+	fw.buf[0] = b
+	fw.write(fw.buf[:1])
+}
+
+func (fw *formatWriter) writeInt(i int) {
+	fw.write(strconv.AppendInt(fw.buf[:0], int64(i), 10))
+}
+
 // Formats the error in Java style.
 // Example:
 //   failed to process: something went wrong
 type GoStyleFormatter struct {
 }
-var _ ErrorFormatter = (*GoStyleFormatter)(nil)
+var _ StreamFormatter = (*GoStyleFormatter)(nil)
 func (f *GoStyleFormatter) Format(err error) string {
-	sb := strings.Builder{}
-	sb.Len()
-	curr := err
-	for curr != nil {
-		if sb.Len() > 0 {
-			sb.WriteString(": ")
+	return formatString(f, err)
+}
+
+func (f *GoStyleFormatter) FormatTo(w io.Writer, err error) error {
+	fw := newFormatWriter(w)
+	if err != nil {
+		writeGoStyle(fw, err)
+	}
+	return fw.err
+}
+
+// Writes the messages of the chain, the errors of a join being bracketed and separated by semicolons,
+// e.g. "failed to process: [first failed: something went wrong; second failed]".
+func writeGoStyle(fw *formatWriter, err error) {
+	if betterr, ok := err.(*BetterError); ok {
+		fw.writeString(redactMsg(betterr.Message()))
+		if betterr.Wrapped != nil {
+			fw.writeString(": ")
+			writeGoStyle(fw, betterr.Wrapped)
 		}
-		if betterr, ok := curr.(*BetterError); ok {
-			sb.WriteString(betterr.Msg)
-			curr = betterr.Wrapped
-		} else {
-			sb.WriteString(curr.Error())
-			break
+		return
+	}
+	members := joinMembers(err)
+	switch {
+	case members == nil:
+		fw.writeString(redactMsg(err.Error()))
+	case len(members) == 1:
+		writeGoStyle(fw, members[0])
+	default:
+		fw.writeByte('[')
+		for i, member := range members {
+			if i > 0 {
+				fw.writeString("; ")
+			}
+			writeGoStyle(fw, member)
 		}
+		fw.writeByte(']')
 	}
-	return sb.String()
 }
 
 // Formats the error in Java style.
@@ -53,43 +149,92 @@ func (f *GoStyleFormatter) Format(err error) string {
 //       at github.com/myapp.main (main.go:45)
 //   Caused by: something went wrong
 //       at github.com/myapp.OtherFunction (file.go:100)
+// Errors received from another service (see [RemoteServiceKey]) are introduced by "Caused by (remote service name): ".
+// The errors of a join (see errors.Join) are printed one after the other, each with its own stack trace.
 type JavaStyleFormatter struct {
+	// Paths selects how the files of the frames are displayed (default [AbsolutePath]).
+	Paths PathStyle
 }
-var _ ErrorFormatter = (*JavaStyleFormatter)(nil)
+var _ StreamFormatter = (*JavaStyleFormatter)(nil)
 func (f *JavaStyleFormatter) Format(err error) string {
-	sb := strings.Builder{}
-	sb.Len()
-	curr := err
-	for curr != nil {
-		if sb.Len() > 0 {
-			sb.WriteString("Caused by: ")
+	return formatString(f, err)
+}
+
+func (f *JavaStyleFormatter) FormatTo(w io.Writer, err error) error {
+	fw := newFormatWriter(w)
+	// The errors that are not BetterErrors end their chain, with no newline when they have no stack trace
+	pendingNewline := false
+	for _, curr := range formatLayers(err) {
+		if pendingNewline {
+			fw.writeByte('\n')
 		}
-		if betterr, ok := curr.(*BetterError); ok {
-			sb.WriteString(betterr.Msg)
-			sb.WriteByte('\n')
-			for _, frame := range betterr.Stack.GetFrames() {
-				sb.WriteString("    at ")
-				sb.WriteString(frame.Function)
-				sb.WriteString(" (")
-				sb.WriteString(frame.File)
-				sb.WriteByte(':')
-				sb.WriteString(strconv.Itoa(frame.Line))
-				sb.WriteString(")\n")
+		betterr, ok := curr.(*BetterError)
+		if ok && betterr.remoteService() != "" {
+			if fw.n > 0 {
+				fw.writeString("Caused by ")
 			}
-			curr = betterr.Wrapped
-		} else {
-			sb.WriteString(curr.Error())
-			break
+			fw.writeString("(remote service ")
+			fw.writeString(betterr.remoteService())
+			fw.writeString("): ")
+		} else if fw.n > 0 {
+			fw.writeString("Caused by: ")
+		}
+		r := Redact(curr)
+		fw.writeString(r.Msg)
+		pendingNewline = !ok && len(r.Frames) == 0
+		if pendingNewline {
+			continue
+		}
+		fw.writeByte('\n')
+		for _, frame := range r.Frames {
+			fw.writeString("    at ")
+			fw.writeString(frame.Function)
+			fw.writeString(" (")
+			fw.writeString(frame.Path(f.Paths))
+			fw.writeByte(':')
+			fw.writeInt(frame.Line)
+			fw.writeString(")\n")
 		}
 	}
-	return sb.String()
+	return fw.err
 }
 
-
+// Returns the errors of the tree printed as layers by the formatters, in depth-first order:
+// the BetterErrors, and the errors of other libraries ending their chain.
+// The joins (see errors.Join) are not layers, their errors are, unless the join is the outermost error.
+func formatLayers(err error) []error {
+	var layers []error
+	var visit func(curr error)
+	visit = func(curr error) {
+		if curr == nil {
+			return
+		}
+		if betterr, ok := curr.(*BetterError); ok {
+			layers = append(layers, curr)
+			visit(betterr.Wrapped)
+			return
+		}
+		members := joinMembers(curr)
+		if members == nil {
+			layers = append(layers, curr)
+			return
+		}
+		if len(layers) == 0 && len(members) > 1 {
+			layers = append(layers, curr)
+		}
+		for _, member := range members {
+			visit(member)
+		}
+	}
+	visit(err)
+	return layers
+}
 
 // Formats the error in JSON.
+// The fingerprint of the error, see [Fingerprint], is added at the top level.
 // Example:
 //   {
+//       "fingerprint": "5f0c6b3e9a2d4c71",
 //       "message": "failed to process",
 //       "stack": [
 //           {
@@ -103,6 +248,9 @@ func (f *JavaStyleFormatter) Format(err error) string {
 //               "line": 45
 //           }
 //       ],
+//       "attributes": {
+//           "item": 123
+//       },
 //       "cause": {
 //           "message": "something went wrong",
 //           "stack": [
@@ -114,36 +262,232 @@ func (f *JavaStyleFormatter) Format(err error) string {
 //           ]
 //       }
 //   }
+// The errors of a join (see errors.Join) are written in a "causes" array instead of the "cause" object.
 type JsonFormatter struct {
+	// ModuleFields adds the "package", "module", "module_version" and "rel_file" fields to the frames (default false).
+	ModuleFields bool
 }
-var _ ErrorFormatter = (*JavaStyleFormatter)(nil)
+var _ StreamFormatter = (*JsonFormatter)(nil)
 func (f *JsonFormatter) Format(err error) string {
-	type jsonError struct {
-		Message string      `json:"message"`
-		Stack  []StackFrames `json:"stack,omitempty"`
-		Cause   *jsonError  `json:"cause,omitempty"`
+	return formatString(f, err)
+}
+
+// The JSON is written by hand, layer by layer, to avoid building the whole document in memory.
+// The output is the same as encoding/json would produce.
+func (f *JsonFormatter) FormatTo(w io.Writer, err error) error {
+	fw := newFormatWriter(w)
+	fw.writeByte('{')
+	if fingerprint := Fingerprint(err); fingerprint != "" {
+		fw.writeString(`"fingerprint":`)
+		writeJSONString(fw, fingerprint)
+		fw.writeByte(',')
+	}
+	if members := joinMembers(err); len(members) == 1 {
+		err = members[0]
 	}
+	f.writeJSONLayer(fw, err)
+	fw.writeByte('}')
+	return fw.err
+}
 
-	curr := err
-	root := &jsonError{}
-	current := root
+// Writes the fields of the error, from its message to its causes, without the braces of the object.
+func (f *JsonFormatter) writeJSONLayer(fw *formatWriter, err error) {
+	fw.writeString(`"message":`)
+	if err == nil {
+		writeJSONString(fw, "")
+		return
+	}
+	betterr, ok := err.(*BetterError)
+	if !ok {
+		r := Redact(err)
+		writeJSONString(fw, r.Msg)
+		f.writeJSONFrames(fw, r.Frames)
+		// Only the outermost join is a layer, see formatLayers
+		if members := joinMembers(err); members != nil {
+			f.writeJSONCauses(fw, members)
+		}
+		return
+	}
+	r := Redact(betterr)
+	writeJSONString(fw, r.Msg)
+	if betterr.Template != "" {
+		fw.writeString(`,"template":`)
+		writeJSONString(fw, r.Template)
+		fw.writeString(`,"args":[`)
+		for i, arg := range r.Args {
+			if i > 0 {
+				fw.writeByte(',')
+			}
+			writeJSONValue(fw, arg)
+		}
+		fw.writeByte(']')
+	}
+	if betterr.Kind != nil {
+		fw.writeString(`,"kind":`)
+		writeJSONString(fw, betterr.Kind.Error())
+		fw.writeString(`,"details":`)
+		writeJSONValue(fw, r.Details)
+	}
+	f.writeJSONFrames(fw, r.Frames)
+	if len(r.Attrs) > 0 {
+		fw.writeString(`,"attributes":`)
+		writeJSONAttrs(fw, r.Attrs)
+	}
+	if betterr.Wrapped == nil {
+		return
+	}
+	members := joinMembers(betterr.Wrapped)
+	if members == nil {
+		members = []error{betterr.Wrapped}
+	}
+	f.writeJSONCauses(fw, members)
+}
 
-	for curr != nil {
-		if betterr, ok := curr.(*BetterError); ok {
-			current.Message = betterr.Msg
-			current.Stack = betterr.Stack.GetFrames()
+// Writes the "cause" object, or the "causes" array for the errors of a join, preceded by a comma.
+func (f *JsonFormatter) writeJSONCauses(fw *formatWriter, causes []error) {
+	if len(causes) == 1 {
+		fw.writeString(`,"cause":{`)
+		f.writeJSONLayer(fw, causes[0])
+		fw.writeByte('}')
+		return
+	}
+	fw.writeString(`,"causes":[`)
+	for i, cause := range causes {
+		if i > 0 {
+			fw.writeByte(',')
+		}
+		fw.writeByte('{')
+		f.writeJSONLayer(fw, cause)
+		fw.writeByte('}')
+	}
+	fw.writeByte(']')
+}
 
-			if betterr.Wrapped != nil {
-				current.Cause = &jsonError{}
-				current = current.Cause
-			}
-			curr = betterr.Wrapped
-		} else {
-			current.Message = curr.Error()
-			break
+// Writes the "stack" field, preceded by a comma, unless there are no frames.
+func (f *JsonFormatter) writeJSONFrames(fw *formatWriter, frames []StackFrames) {
+	if len(frames) == 0 {
+		return
+	}
+	fw.writeString(`,"stack":[`)
+	for i, frame := range frames {
+		if i > 0 {
+			fw.writeByte(',')
+		}
+		fw.writeString(`{"function":`)
+		writeJSONString(fw, frame.Function)
+		fw.writeString(`,"file":`)
+		writeJSONString(fw, frame.File)
+		fw.writeString(`,"line":`)
+		fw.writeInt(frame.Line)
+		if f.ModuleFields {
+			writeJSONOptionalField(fw, "package", frame.Package)
+			writeJSONOptionalField(fw, "module", frame.Module)
+			writeJSONOptionalField(fw, "module_version", frame.ModuleVersion)
+			writeJSONOptionalField(fw, "rel_file", frame.RelFile)
 		}
+		fw.writeByte('}')
+	}
+	fw.writeByte(']')
+}
+
+// Writes the field, preceded by a comma, unless the value is empty.
+func writeJSONOptionalField(fw *formatWriter, key, value string) {
+	if value == "" {
+		return
 	}
+	fw.writeString(`,"`)
+	fw.writeString(key)
+	fw.writeString(`":`)
+	writeJSONString(fw, value)
+}
 
-	result, _ := json.Marshal(root)
-	return string(result)
+// Writes the attributes as a JSON object, sorted by key like encoding/json does for maps.
+// When the same key is attached several times, the last value wins.
+func writeJSONAttrs(fw *formatWriter, attrs []Attr) {
+	values := make(map[string]any, len(attrs))
+	keys := make([]string, 0, len(attrs))
+	for _, attr := range attrs {
+		if _, ok := values[attr.Key]; !ok {
+			keys = append(keys, attr.Key)
+		}
+		values[attr.Key] = attr.Value
+	}
+	sort.Strings(keys)
+	fw.writeByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			fw.writeByte(',')
+		}
+		writeJSONString(fw, key)
+		fw.writeByte(':')
+		writeJSONValue(fw, values[key])
+	}
+	fw.writeByte('}')
+}
+
+// Writes the value as encoding/json would.
+// Unsupported values (channels, functions, ...) are written as their string representation.
+func writeJSONValue(fw *formatWriter, value any) {
+	marshaled, err := json.Marshal(value)
+	if err != nil {
+		writeJSONString(fw, fmt.Sprint(value))
+		return
+	}
+	fw.write(marshaled)
+}
+
+const hexDigits = "0123456789abcdef"
+
+// Writes s as a JSON string, escaping it the same way as encoding/json (including HTML characters).
+func writeJSONString(fw *formatWriter, s string) {
+	fw.writeByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		b := s[i]
+		if b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			fw.writeString(s[start:i])
+			switch b {
+			case '"', '\\':
+				fw.writeByte('\\')
+				fw.writeByte(b)
+			case '\n':
+				fw.writeString(`\n`)
+			case '\r':
+				fw.writeString(`\r`)
+			case '\t':
+				fw.writeString(`\t`)
+			default:
+				fw.writeString(`\u00`)
+				fw.writeByte(hexDigits[b>>4])
+				fw.writeByte(hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			fw.writeString(s[start:i])
+			fw.writeString(`\ufffd`)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 and U+2029 are valid JSON but break JavaScript, encoding/json escapes them
+		if r == '\u2028' || r == '\u2029' {
+			fw.writeString(s[start:i])
+			fw.writeString(`\u202`)
+			fw.writeByte(hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	fw.writeString(s[start:])
+	fw.writeByte('"')
 }
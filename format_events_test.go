@@ -0,0 +1,19 @@
+package betterr
+
+import (
+	"testing"
+)
+
+func TestSentryFormatter_InApp(t *testing.T) {
+	f := &SentryFormatter{}
+	assertTrue(t, f.inApp("github.com/myapp", "/src/myapp/file.go"))
+	assertTrue(t, f.inApp("main", "/src/myapp/main.go"))
+	assertFalse(t, f.inApp("runtime", "/usr/local/go/src/runtime/proc.go"))
+	assertFalse(t, f.inApp("net/http", "/usr/local/go/src/net/http/server.go"))
+	assertFalse(t, f.inApp("github.com/lib/pq", "/home/ci/go/pkg/mod/github.com/lib/pq@v1.10.0/conn.go"))
+	assertFalse(t, f.inApp("github.com/lib/pq", "/src/myapp/vendor/github.com/lib/pq/conn.go"))
+
+	f = &SentryFormatter{InAppPrefixes: []string{"github.com/myapp/internal"}}
+	assertTrue(t, f.inApp("github.com/myapp/internal/db", "/src/myapp/internal/db/db.go"))
+	assertFalse(t, f.inApp("github.com/myapp", "/src/myapp/main.go"))
+}
@@ -0,0 +1,121 @@
+package betterr
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Formats the error as a Google Cloud Error Reporting ReportedErrorEvent, to be written as a structured log entry.
+// The stack trace is rendered like a Go panic, which Error Reporting parses to group errors.
+// The stack is the one of the deepest BetterError in the chain, where the error originated.
+// Example:
+//   {
+//       "@type": "type.googleapis.com/google.devtools.clouderrorreporting.v1beta1.ReportedErrorEvent",
+//       "message": "failed to process: something went wrong",
+//       "stack_trace": "panic: failed to process: something went wrong\n\ngoroutine 1 [running]:\ngithub.com/myapp.OtherFunction(...)\n\tfile.go:42\n",
+//       "serviceContext": {
+//           "service": "myapp",
+//           "version": "1.0.0"
+//       },
+//       "context": {
+//           "reportLocation": {
+//               "filePath": "file.go",
+//               "lineNumber": 42,
+//               "functionName": "github.com/myapp.OtherFunction"
+//           }
+//       }
+//   }
+type GCPErrorReportingFormatter struct {
+	// Service and Version identify the reporting service in Error Reporting, both are optional.
+	Service string
+	Version string
+}
+
+var _ StreamFormatter = (*GCPErrorReportingFormatter)(nil)
+
+type gcpReportedErrorEvent struct {
+	Type           string             `json:"@type"`
+	Message        string             `json:"message"`
+	StackTrace     string             `json:"stack_trace,omitempty"`
+	ServiceContext *gcpServiceContext `json:"serviceContext,omitempty"`
+	Context        *gcpErrorContext   `json:"context,omitempty"`
+}
+
+type gcpServiceContext struct {
+	Service string `json:"service,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+type gcpErrorContext struct {
+	ReportLocation gcpSourceLocation `json:"reportLocation"`
+}
+
+type gcpSourceLocation struct {
+	FilePath     string `json:"filePath"`
+	LineNumber   int    `json:"lineNumber"`
+	FunctionName string `json:"functionName"`
+}
+
+func (f *GCPErrorReportingFormatter) Format(err error) string {
+	return formatString(f, err)
+}
+
+func (f *GCPErrorReportingFormatter) FormatTo(w io.Writer, err error) error {
+	message := new(GoStyleFormatter).Format(err)
+	event := gcpReportedErrorEvent{
+		Type:    "type.googleapis.com/google.devtools.clouderrorreporting.v1beta1.ReportedErrorEvent",
+		Message: message,
+	}
+	if f.Service != "" || f.Version != "" {
+		event.ServiceContext = &gcpServiceContext{Service: f.Service, Version: f.Version}
+	}
+
+	var frames []StackFrames
+	for curr := err; curr != nil; {
+		betterr, ok := curr.(*BetterError)
+		if members := joinMembers(curr); !ok && len(members) > 0 {
+			// The origin of a join is the one of its first error, like its Cause
+			curr = members[0]
+			continue
+		}
+		if !ok {
+			if foreignFrames := Redact(curr).Frames; len(foreignFrames) > 0 {
+				frames = foreignFrames
+			}
+			break
+		}
+		frames = Redact(betterr).Frames
+		curr = betterr.Wrapped
+	}
+	if len(frames) > 0 {
+		sb := strings.Builder{}
+		sb.WriteString("panic: ")
+		sb.WriteString(message)
+		sb.WriteString("\n\ngoroutine 1 [running]:\n")
+		for _, frame := range frames {
+			sb.WriteString(frame.Function)
+			sb.WriteString("(...)\n\t")
+			sb.WriteString(frame.File)
+			sb.WriteByte(':')
+			sb.WriteString(strconv.Itoa(frame.Line))
+			sb.WriteByte('\n')
+		}
+		event.StackTrace = sb.String()
+		event.Context = &gcpErrorContext{
+			ReportLocation: gcpSourceLocation{
+				FilePath:     frames[0].File,
+				LineNumber:   frames[0].Line,
+				FunctionName: frames[0].Function,
+			},
+		}
+	}
+
+	data, marshalErr := json.Marshal(event)
+	if marshalErr != nil {
+		return marshalErr
+	}
+	_, writeErr := w.Write(data)
+	return writeErr
+}
@@ -0,0 +1,173 @@
+package betterr
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Formats the error as a Sentry event payload.
+// Each error of the chain becomes an entry of the exception values, ordered from the deepest cause to the outermost error
+// (the errors of the joins, see errors.Join, are listed in reverse depth-first order),
+// and the frames of each stack are ordered from the oldest call to the most recent one, as Sentry expects.
+// The attributes of the chain are sent as extra data, the outermost value wins when a key is attached several times.
+// Example:
+//   {
+//       "platform": "go",
+//       "level": "error",
+//       "exception": {
+//           "values": [
+//               {
+//                   "type": "*betterr.BetterError",
+//                   "value": "something went wrong",
+//                   "stacktrace": {
+//                       "frames": [
+//                           {
+//                               "function": "OtherFunction",
+//                               "module": "github.com/myapp",
+//                               "filename": "file.go",
+//                               "abs_path": "/src/myapp/file.go",
+//                               "lineno": 42,
+//                               "in_app": true
+//                           }
+//                       ]
+//                   }
+//               },
+//               {
+//                   "type": "*betterr.BetterError",
+//                   "value": "failed to process",
+//                   ...
+//               }
+//           ]
+//       }
+//   }
+type SentryFormatter struct {
+	// InAppPrefixes lists the package path prefixes of the application code.
+	// When empty, frames are considered in-app unless they come from the standard library, the module cache or a vendor directory.
+	InAppPrefixes []string
+}
+
+var _ StreamFormatter = (*SentryFormatter)(nil)
+
+// SentryEvent is the subset of the Sentry event payload produced by [SentryFormatter].
+type SentryEvent struct {
+	EventID     string           `json:"event_id,omitempty"`
+	Timestamp   string           `json:"timestamp,omitempty"`
+	Platform    string           `json:"platform"`
+	Level       string           `json:"level"`
+	Environment string           `json:"environment,omitempty"`
+	Release     string           `json:"release,omitempty"`
+	ServerName  string           `json:"server_name,omitempty"`
+	Exception   SentryExceptions `json:"exception"`
+	Extra       map[string]any   `json:"extra,omitempty"`
+}
+
+type SentryExceptions struct {
+	Values []SentryException `json:"values"`
+}
+
+type SentryException struct {
+	Type       string            `json:"type"`
+	Value      string            `json:"value"`
+	Stacktrace *SentryStacktrace `json:"stacktrace,omitempty"`
+}
+
+type SentryStacktrace struct {
+	Frames []SentryFrame `json:"frames"`
+}
+
+type SentryFrame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	AbsPath  string `json:"abs_path,omitempty"`
+	Lineno   int    `json:"lineno,omitempty"`
+	InApp    bool   `json:"in_app"`
+}
+
+func (f *SentryFormatter) Format(err error) string {
+	return formatString(f, err)
+}
+
+func (f *SentryFormatter) FormatTo(w io.Writer, err error) error {
+	data, marshalErr := json.Marshal(f.Event(err))
+	if marshalErr != nil {
+		return marshalErr
+	}
+	_, writeErr := w.Write(data)
+	return writeErr
+}
+
+// Builds the Sentry event of an error chain.
+// EventID, Timestamp and the fields describing the application are left empty, they are set by the code sending the event.
+func (f *SentryFormatter) Event(err error) *SentryEvent {
+	event := &SentryEvent{
+		Platform: "go",
+		Level:    "error",
+	}
+	var values []SentryException
+	for _, curr := range formatLayers(err) {
+		exception := SentryException{Type: reflect.TypeOf(curr).String()}
+		r := Redact(curr)
+		exception.Value = r.Msg
+		if frames := r.Frames; len(frames) > 0 {
+			exception.Stacktrace = &SentryStacktrace{Frames: make([]SentryFrame, len(frames))}
+			for i, frame := range frames {
+				exception.Stacktrace.Frames[len(frames)-1-i] = f.frame(frame)
+			}
+		}
+		if betterr, ok := curr.(*BetterError); ok {
+			if betterr.Kind != nil {
+				// Kinds are the equivalent of exception classes
+				exception.Type = betterr.Kind.Error()
+			}
+			for _, attr := range r.Attrs {
+				if event.Extra == nil {
+					event.Extra = map[string]any{}
+				}
+				if _, exists := event.Extra[attr.Key]; !exists {
+					event.Extra[attr.Key] = attr.Value
+				}
+			}
+		}
+		values = append(values, exception)
+	}
+	// Sentry expects the deepest cause first
+	for i, j := 0, len(values)-1; i < j; i, j = i+1, j-1 {
+		values[i], values[j] = values[j], values[i]
+	}
+	event.Exception.Values = values
+	return event
+}
+
+func (f *SentryFormatter) frame(frame StackFrames) SentryFrame {
+	pkg, name := SplitFunctionName(frame.Function)
+	filename := frame.RelFile
+	if filename == "" {
+		filename = frame.Path(BaseName)
+	}
+	return SentryFrame{
+		Function: name,
+		Module:   pkg,
+		Filename: filename,
+		AbsPath:  frame.File,
+		Lineno:   frame.Line,
+		InApp:    f.inApp(pkg, frame.File),
+	}
+}
+
+func (f *SentryFormatter) inApp(pkg, file string) bool {
+	if len(f.InAppPrefixes) > 0 {
+		for _, prefix := range f.InAppPrefixes {
+			if strings.HasPrefix(pkg, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	if strings.Contains(file, "/pkg/mod/") || strings.Contains(file, "/vendor/") {
+		return false
+	}
+	return pkg != "" && !isStdPackage(pkg)
+}
@@ -0,0 +1,104 @@
+package betterr
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var allFormatters = []ErrorFormatter{
+	&GoStyleFormatter{},
+	&JavaStyleFormatter{},
+	&JsonFormatter{},
+	MustTemplateFormatter(JavaStyleTemplate),
+}
+
+func TestFormatTo_SameAsFormat(t *testing.T) {
+	errs := []error{
+		nil,
+		errors.New("A plain Go error"),
+		New("A BetterError error"),
+		mockedChain(),
+	}
+	for _, f := range allFormatters {
+		for _, err := range errs {
+			sb := strings.Builder{}
+			assertNoError(t, FormatTo(&sb, f, err))
+			assertEqual(t, f.Format(err), sb.String())
+		}
+	}
+}
+
+// Formatter implementing only [ErrorFormatter], as the custom formatters written before [StreamFormatter]
+type stringFormatter struct{}
+
+func (f stringFormatter) Format(err error) string {
+	return "formatted: " + err.Error()
+}
+
+func TestFormatTo_StringFormatter(t *testing.T) {
+	sb := strings.Builder{}
+	assertNoError(t, FormatTo(&sb, stringFormatter{}, errors.New("A plain Go error")))
+	assertEqual(t, "formatted: A plain Go error", sb.String())
+	assertTrue(t, FormatTo(&failingWriter{}, stringFormatter{}, errors.New("A plain Go error")) != nil)
+}
+
+type failingWriter struct {
+	calls int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.calls++
+	return 0, errors.New("disk full")
+}
+
+func TestFormatTo_WriteError(t *testing.T) {
+	for _, f := range allFormatters {
+		w := &failingWriter{}
+		err := FormatTo(w, f, mockedChain())
+		assertTrue(t, err != nil)
+		// Formatters should stop writing after the first failure
+		assertEqual(t, 1, w.calls)
+	}
+}
+
+func TestJsonFormatter_SameAsEncodingJson(t *testing.T) {
+	messages := []string{
+		`quotes " and backslashes \`,
+		"control\n\r\t\x00\x1f characters",
+		"<html> & co",
+		"unicode é 日本    ",
+		"invalid \xff utf-8",
+	}
+	for _, msg := range messages {
+		err := &BetterError{
+			Msg:   msg,
+			Stack: &mockedStacktrace{frames: []StackFrames{{Function: msg, File: msg, Line: -1}}},
+			Attrs: []Attr{{Key: msg, Value: msg}, {Key: "z", Value: []int{1}}, {Key: "a", Value: nil}},
+		}
+		expected, marshalErr := json.Marshal(map[string]any{
+			"fingerprint": Fingerprint(err),
+			"message":    msg,
+			"stack":      []StackFrames{{Function: msg, File: msg, Line: -1}},
+			"attributes": map[string]any{msg: msg, "z": []int{1}, "a": nil},
+		})
+		assertNoError(t, marshalErr)
+		assertJSONEq(t, string(expected), new(JsonFormatter).Format(err))
+		assertTrue(t, json.Valid([]byte(new(JsonFormatter).Format(err))))
+	}
+}
+
+func TestJsonFormatter_ModuleFields(t *testing.T) {
+	frame := StackFrames{Function: "github.com/lib/pq.(*conn).Exec", File: "/go/pkg/mod/github.com/lib/pq@v1.10.0/conn.go", Line: 42,
+		Package: "github.com/lib/pq", Module: "github.com/lib/pq", ModuleVersion: "v1.10.0", RelFile: "conn.go"}
+	err := &BetterError{Msg: "msg", Stack: &mockedStacktrace{frames: []StackFrames{frame}}}
+	assertFalse(t, strings.Contains(new(JsonFormatter).Format(err), "module"))
+	assertTrue(t, strings.Contains((&JsonFormatter{ModuleFields: true}).Format(err),
+		`"line":42,"package":"github.com/lib/pq","module":"github.com/lib/pq","module_version":"v1.10.0","rel_file":"conn.go"}`))
+}
+
+func TestJsonFormatter_UnsupportedAttribute(t *testing.T) {
+	err := &BetterError{Msg: "msg", Stack: &mockedStacktrace{}, Attrs: []Attr{{Key: "ch", Value: make(chan int)}}}
+	assertTrue(t, json.Valid([]byte(new(JsonFormatter).Format(err))))
+}
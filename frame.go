@@ -0,0 +1,180 @@
+package betterr
+
+import (
+	"path"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// PathStyle selects how formatters display the file of the frames.
+type PathStyle int
+
+const (
+	// The absolute path on the build machine, e.g. "/home/ci/go/pkg/mod/github.com/lib/pq@v1.10.0/conn.go".
+	AbsolutePath PathStyle = iota
+	// The module-relative path prefixed by the module and its version, e.g. "github.com/lib/pq@v1.10.0/conn.go".
+	// Files of the standard library are prefixed by nothing, e.g. "net/http/server.go".
+	ModulePath
+	// The path relative to the root of the module, e.g. "conn.go".
+	RelativePath
+	// The name of the file, e.g. "conn.go".
+	BaseName
+)
+
+// Returns the file of the frame in the given style.
+// Falls back to the absolute path when the module of the frame is unknown.
+func (f StackFrames) Path(style PathStyle) string {
+	switch style {
+	case ModulePath:
+		if f.Module == "" || f.RelFile == "" {
+			return f.File
+		}
+		if f.Module == stdModule {
+			return f.RelFile
+		}
+		if f.ModuleVersion != "" && f.ModuleVersion != develVersion {
+			return f.Module + "@" + f.ModuleVersion + "/" + f.RelFile
+		}
+		return f.Module + "/" + f.RelFile
+	case RelativePath:
+		if f.RelFile == "" {
+			return f.File
+		}
+		return f.RelFile
+	case BaseName:
+		return path.Base(f.File)
+	default:
+		return f.File
+	}
+}
+
+// Returns whether the frame is in the standard library.
+// Frames whose module is unknown, e.g. parsed ones, are recognized by their package.
+func (f StackFrames) IsStd() bool {
+	if f.Module != "" {
+		return f.Module == stdModule
+	}
+	pkg, _ := SplitFunctionName(f.Function)
+	module := lookupModule(pkg)
+	return module != nil && module.path == stdModule
+}
+
+const (
+	stdModule    = "std"
+	develVersion = "(devel)"
+)
+
+type moduleInfo struct {
+	path    string
+	version string
+}
+
+var (
+	buildModulesOnce sync.Once
+	mainModule       moduleInfo
+	// Import path of the main package of the binary, e.g. "github.com/myapp/cmd/server"
+	mainPackage string
+	depModules  []moduleInfo
+	// Package path -> *moduleInfo, nil for unknown modules
+	packageModules sync.Map
+)
+
+func loadBuildModules() {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	mainModule = moduleInfo{path: info.Main.Path, version: info.Main.Version}
+	mainPackage = info.Path
+	for _, dep := range info.Deps {
+		version := dep.Version
+		if dep.Replace != nil {
+			version = dep.Replace.Version
+		}
+		depModules = append(depModules, moduleInfo{path: dep.Path, version: version})
+	}
+}
+
+// Returns the module providing the package, nil if it is unknown.
+func lookupModule(pkg string) *moduleInfo {
+	if cached, ok := packageModules.Load(pkg); ok {
+		return cached.(*moduleInfo)
+	}
+	buildModulesOnce.Do(loadBuildModules)
+
+	var found *moduleInfo
+	if pkg == "main" || isInModule(pkg, mainModule.path) {
+		found = &mainModule
+	}
+	for i := range depModules {
+		// The longest module path wins, modules can be nested (e.g. "cloud.google.com/go" and "cloud.google.com/go/storage")
+		if isInModule(pkg, depModules[i].path) && (found == nil || len(depModules[i].path) > len(found.path)) {
+			found = &depModules[i]
+		}
+	}
+	if found == nil && isStdPackage(pkg) {
+		found = &moduleInfo{path: stdModule, version: runtime.Version()}
+	}
+	packageModules.Store(pkg, found)
+	return found
+}
+
+// The first element of standard library packages has no dot, unlike modules hosted somewhere.
+func isStdPackage(pkg string) bool {
+	first, _, _ := strings.Cut(pkg, "/")
+	return pkg != "" && pkg != "main" && !strings.Contains(first, ".")
+}
+
+func isInModule(pkg, module string) bool {
+	return module != "" && (pkg == module || strings.HasPrefix(pkg, module+"/"))
+}
+
+// Fills the package, module and module-relative file of the frame from its function and file.
+// The module-relative file is derived from the package path, as the directory of a package matches its import path in its module.
+func resolveModule(frame *StackFrames) {
+	pkg, _ := SplitFunctionName(frame.Function)
+	if pkg == "" {
+		return
+	}
+	frame.Package = pkg
+	// External test packages live in the directory of the package they test
+	pkg = strings.TrimSuffix(pkg, "_test")
+	module := lookupModule(pkg)
+	if module == nil {
+		return
+	}
+	frame.Module = module.path
+	frame.ModuleVersion = module.version
+	base := path.Base(frame.File)
+	switch {
+	case module.path == stdModule:
+		frame.RelFile = pkg + "/" + base
+	case pkg == "main":
+		// Functions of main packages are named after "main" instead of their import path
+		frame.RelFile = mainRelFile(mainPackage, module.path, frame.File)
+	case pkg == module.path:
+		frame.RelFile = base
+	default:
+		frame.RelFile = strings.TrimPrefix(pkg, module.path+"/") + "/" + base
+	}
+}
+
+// Returns the file of the main package relative to the root of its module.
+// The directory comes from the import path of the main package when the file is in it,
+// otherwise from the module path found in the file path, e.g. in a GOPATH checkout.
+// Falls back to the name of the file, assuming the main package is at the root of the module.
+func mainRelFile(mainPkg, modulePath, file string) string {
+	base := path.Base(file)
+	if modulePath == "" || mainPkg == modulePath {
+		return base
+	}
+	if dir := strings.TrimPrefix(mainPkg, modulePath+"/"); dir != mainPkg && strings.HasSuffix(path.Dir(file), "/"+dir) {
+		return dir + "/" + base
+	}
+	if idx := strings.LastIndex(file, "/"+modulePath+"/"); idx >= 0 {
+		return file[idx+len(modulePath)+2:]
+	}
+	return base
+}
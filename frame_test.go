@@ -0,0 +1,199 @@
+package betterr
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRuntimeStacktrace_ResolvesModules(t *testing.T) {
+	frames := method_1deep().Stack.GetFrames()
+
+	assertEqual(t, "github.com/jjunac/betterr", frames[0].Package)
+	assertEqual(t, "github.com/jjunac/betterr", frames[0].Module)
+	assertEqual(t, "(devel)", frames[0].ModuleVersion)
+	assertEqual(t, "stacktrace_test.go", frames[0].RelFile)
+
+	// testing.tRunner
+	assertEqual(t, "testing", frames[2].Package)
+	assertEqual(t, "std", frames[2].Module)
+	assertEqual(t, runtime.Version(), frames[2].ModuleVersion)
+	assertEqual(t, "testing/testing.go", frames[2].RelFile)
+}
+
+func TestResolveModule(t *testing.T) {
+	buildModulesOnce.Do(loadBuildModules)
+	previous := depModules
+	depModules = append(depModules,
+		moduleInfo{path: "cloud.google.com/go", version: "v0.110.0"},
+		moduleInfo{path: "cloud.google.com/go/storage", version: "v1.30.0"},
+	)
+	defer func() {
+		depModules = previous
+	}()
+
+	testCases := []struct {
+		frame    StackFrames
+		expected StackFrames
+	}{
+		{
+			frame: StackFrames{Function: "cloud.google.com/go/storage/internal.(*Reader).Read", File: "/go/pkg/mod/cloud.google.com/go/storage@v1.30.0/internal/reader.go"},
+			expected: StackFrames{Package: "cloud.google.com/go/storage/internal", Module: "cloud.google.com/go/storage", ModuleVersion: "v1.30.0",
+				RelFile: "internal/reader.go"},
+		},
+		{
+			frame:    StackFrames{Function: "cloud.google.com/go/civil.Date.String", File: "/go/pkg/mod/cloud.google.com/go@v0.110.0/civil/civil.go"},
+			expected: StackFrames{Package: "cloud.google.com/go/civil", Module: "cloud.google.com/go", ModuleVersion: "v0.110.0", RelFile: "civil/civil.go"},
+		},
+		{
+			frame:    StackFrames{Function: "github.com/jjunac/betterr/sentry.(*Client).run.func1", File: "/src/betterr/sentry/client.go"},
+			expected: StackFrames{Package: "github.com/jjunac/betterr/sentry", Module: "github.com/jjunac/betterr", ModuleVersion: "(devel)", RelFile: "sentry/client.go"},
+		},
+		{
+			frame:    StackFrames{Function: "github.com/jjunac/betterr_test.TestSomething", File: "/src/betterr/betterr_test.go"},
+			expected: StackFrames{Package: "github.com/jjunac/betterr_test", Module: "github.com/jjunac/betterr", ModuleVersion: "(devel)", RelFile: "betterr_test.go"},
+		},
+		{
+			frame:    StackFrames{Function: "example.com/unknown.F", File: "/src/unknown/f.go"},
+			expected: StackFrames{Package: "example.com/unknown"},
+		},
+	}
+	for _, tc := range testCases {
+		frame := tc.frame
+		resolveModule(&frame)
+		tc.expected.Function, tc.expected.File = tc.frame.Function, tc.frame.File
+		assertEqual(t, tc.expected, frame)
+	}
+}
+
+func TestMainRelFile(t *testing.T) {
+	testCases := []struct {
+		mainPkg  string
+		file     string
+		expected string
+	}{
+		{"github.com/myapp/cmd/server", "/src/myapp/cmd/server/main.go", "cmd/server/main.go"},
+		{"github.com/myapp", "/src/myapp/main.go", "main.go"},
+		{"github.com/myapp/cmd/server", "/src/myapp/cmd/other/main.go", "main.go"},
+		{"command-line-arguments", "/go/src/github.com/myapp/cmd/server/main.go", "cmd/server/main.go"},
+		{"command-line-arguments", "/src/myapp/cmd/server/main.go", "main.go"},
+	}
+	for _, tc := range testCases {
+		assertEqual(t, tc.expected, mainRelFile(tc.mainPkg, "github.com/myapp", tc.file))
+	}
+}
+
+func TestStackFrames_IsStd(t *testing.T) {
+	assertTrue(t, StackFrames{Function: "net/http.(*conn).serve"}.IsStd())
+	assertTrue(t, StackFrames{Function: "github.com/myapp.Run", Module: "std"}.IsStd())
+	assertFalse(t, StackFrames{Function: "github.com/myapp.Run"}.IsStd())
+	assertFalse(t, StackFrames{Function: "main.main"}.IsStd())
+	assertFalse(t, StackFrames{Function: "net/http.(*conn).serve", Module: "github.com/myapp"}.IsStd())
+	assertFalse(t, StackFrames{}.IsStd())
+}
+
+func TestStackFrames_Path(t *testing.T) {
+	frame := StackFrames{File: "/go/pkg/mod/github.com/lib/pq@v1.10.0/oid/types.go", Module: "github.com/lib/pq", ModuleVersion: "v1.10.0", RelFile: "oid/types.go"}
+	assertEqual(t, "/go/pkg/mod/github.com/lib/pq@v1.10.0/oid/types.go", frame.Path(AbsolutePath))
+	assertEqual(t, "github.com/lib/pq@v1.10.0/oid/types.go", frame.Path(ModulePath))
+	assertEqual(t, "oid/types.go", frame.Path(RelativePath))
+	assertEqual(t, "types.go", frame.Path(BaseName))
+
+	std := StackFrames{File: "/usr/local/go/src/net/http/server.go", Module: "std", ModuleVersion: "go1.20", RelFile: "net/http/server.go"}
+	assertEqual(t, "net/http/server.go", std.Path(ModulePath))
+
+	devel := StackFrames{File: "/src/myapp/cmd/server/main.go", Module: "github.com/myapp", ModuleVersion: "(devel)", RelFile: "cmd/server/main.go"}
+	assertEqual(t, "github.com/myapp/cmd/server/main.go", devel.Path(ModulePath))
+
+	unknown := StackFrames{File: "/src/unknown/f.go"}
+	assertEqual(t, "/src/unknown/f.go", unknown.Path(ModulePath))
+	assertEqual(t, "/src/unknown/f.go", unknown.Path(RelativePath))
+}
+
+func TestJavaStyleFormatter_Paths(t *testing.T) {
+	output := (&JavaStyleFormatter{Paths: ModulePath}).Format(method_1deep())
+	assertTrue(t, strings.HasPrefix(output, "A BetterError error\n"+
+		"    at github.com/jjunac/betterr.method_1deep (github.com/jjunac/betterr/stacktrace_test.go:9)\n"))
+	assertTrue(t, strings.Contains(output, "    at testing.tRunner (testing/testing.go:"))
+}
+
+func TestParseFunctionName(t *testing.T) {
+	testCases := []struct {
+		function string
+		receiver string
+		name     string
+		closure  bool
+	}{
+		{"github.com/myapp.Run", "", "Run", false},
+		{"github.com/myapp.(*Worker).Run", "*Worker", "Run", false},
+		{"github.com/myapp.Worker.Run", "Worker", "Run", false},
+		{"github.com/myapp.(*Worker).Run.func1", "*Worker", "Run", true},
+		{"github.com/myapp.Worker.Run.func2.1", "Worker", "Run", true},
+		{"github.com/myapp.Run.func1.gowrap1", "", "Run", true},
+		{"github.com/myapp.Map[...]", "", "Map", false},
+		{"github.com/myapp.(*Set[...]).Add.func1", "*Set", "Add", true},
+		{"github.com/myapp.init.0", "", "init", false},
+		{"github.com/myapp.init.func1", "", "init", true},
+		{"github.com/myapp.glob..func1", "", "glob", true},
+		{"github.com/myapp.func1", "", "func1", false},
+		{"main.main", "", "main", false},
+		// Malformed names are kept as is
+		{"github.com/myapp.", "", "github.com/myapp.", false},
+		{"", "", "", false},
+	}
+	for _, tc := range testCases {
+		frame := StackFrames{Function: tc.function}
+		parseFunctionName(&frame)
+		assertEqual(t, StackFrames{Function: tc.function, Receiver: tc.receiver, Name: tc.name, Closure: tc.closure}, frame)
+	}
+}
+
+type frameReceiver struct{}
+
+func (frameReceiver) closure() *BetterError {
+	return func() *BetterError {
+		return method_1deep()
+	}()
+}
+
+func TestRuntimeStacktrace_ParsesFrames(t *testing.T) {
+	frames := frameReceiver{}.closure().Stack.GetFrames()
+
+	assertEqual(t, "method_1deep", frames[0].Name)
+	assertTrue(t, frames[0].PC != 0)
+	assertTrue(t, frames[0].EntryOffset != 0)
+
+	assertEqual(t, "frameReceiver", frames[1].Receiver)
+	assertEqual(t, "closure", frames[1].Name)
+	assertTrue(t, frames[1].Closure)
+
+	assertEqual(t, "frameReceiver", frames[2].Receiver)
+	assertEqual(t, "closure", frames[2].Name)
+	assertFalse(t, frames[2].Closure)
+}
+
+// Small enough to be inlined into its caller.
+func inlinedNew() *BetterError {
+	return New("inlined").(*BetterError)
+}
+
+//go:noinline
+func inliningCaller() *BetterError {
+	return inlinedNew()
+}
+
+func TestRuntimeStacktrace_Inlined(t *testing.T) {
+	frames := inliningCaller().Stack.GetFrames()
+
+	assertEqual(t, "inlinedNew", frames[0].Name)
+	assertTrue(t, frames[0].Inlined)
+
+	assertEqual(t, "inliningCaller", frames[1].Name)
+	assertFalse(t, frames[1].Inlined)
+}
+
+func TestRuntimeStacktrace_SkipTooDeep(t *testing.T) {
+	err := NewSkip(100, "too deep").(*BetterError)
+	assertEqual(t, 0, len(err.Stack.GetFrames()))
+	assertEqual(t, "too deep\n", err.Error())
+}
@@ -0,0 +1,88 @@
+package betterr_test
+
+import (
+	"errors"
+	"flag"
+	"testing"
+
+	"github.com/jjunac/betterr"
+	"github.com/jjunac/betterr/betterrtest"
+)
+
+type goldenCase struct {
+	name string
+	err  error
+}
+
+func goldenCases() []goldenCase {
+	return []goldenCase{
+		{"plain", errors.New("A plain Go error")},
+		{"new", betterr.New("A BetterError error")},
+		{"wrap_plain", betterr.Wrap(errors.New("A wrapped plain Go error"))},
+		{"wrap_betterr", betterr.Wrap(betterr.New("A wrapped BetterError error"))},
+		{"decorate_plain", betterr.Decorate(errors.New("A plain Go error"), "Decorated")},
+		{"decorate_betterr", betterr.Decorate(betterr.New("A BetterError error"), "Decorated")},
+		{"decoratef", betterr.Decoratef(betterr.Decorate(errors.New("A plain Go error"), "Decorated"), "A %s level of decoration", "second")},
+	}
+}
+
+func init() {
+	flag.BoolVar(&betterrtest.Update, "update", betterrtest.Update, "write the golden files instead of comparing them")
+}
+
+// The golden files are in testdata/<test name>/<case>/<formatter>.golden, run the tests with -update to rewrite them.
+func TestErrorFormatter(t *testing.T) {
+	for _, tc := range goldenCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Run("go", func(t *testing.T) {
+				betterrtest.AssertFormat(t, &betterr.GoStyleFormatter{}, tc.err)
+			})
+			t.Run("java", func(t *testing.T) {
+				betterrtest.AssertFormat(t, &betterr.JavaStyleFormatter{}, tc.err)
+			})
+		})
+	}
+}
+
+// The JSON output holds the module of every frame, so the frames of the standard library are dropped
+// to keep the golden files independent of the Go version.
+func TestJsonFormatter(t *testing.T) {
+	betterrtest.UseStableStacktraces(t)
+	for _, tc := range goldenCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			betterrtest.AssertFormat(t, &betterr.JsonFormatter{}, tc.err)
+		})
+	}
+}
+
+// Chain of errors with static stacks, for the formatters whose output depends on the whole frames.
+func eventChain() error {
+	return &betterr.BetterError{
+		Msg: "process failed",
+		Stack: &betterr.StaticStacktrace{Frames: []betterr.StackFrames{
+			{File: "/src/myapp/file.go", Function: "github.com/myapp.MyFunction", Line: 123},
+			{File: "/src/myapp/main.go", Function: "github.com/myapp.main", Line: 45},
+		}},
+		Attrs: []betterr.Attr{{Key: "item", Value: 123}},
+		Wrapped: &betterr.BetterError{
+			Msg: "something went wrong",
+			Stack: &betterr.StaticStacktrace{Frames: []betterr.StackFrames{
+				{File: "/src/myapp/file.go", Function: "github.com/myapp.(*Worker).Run", Line: 42},
+			}},
+			Wrapped: errors.New("connection refused"),
+		},
+	}
+}
+
+func TestGCPErrorReportingFormatter(t *testing.T) {
+	t.Run("chain", func(t *testing.T) {
+		betterrtest.AssertFormat(t, &betterr.GCPErrorReportingFormatter{Service: "myapp", Version: "1.0.0"}, eventChain())
+	})
+	t.Run("plain", func(t *testing.T) {
+		betterrtest.AssertFormat(t, &betterr.GCPErrorReportingFormatter{}, errors.New("connection refused"))
+	})
+}
+
+func TestSentryFormatter(t *testing.T) {
+	betterrtest.AssertFormat(t, &betterr.SentryFormatter{}, eventChain())
+}
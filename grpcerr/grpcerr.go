@@ -0,0 +1,245 @@
+// Package grpcerr converts BetterErrors to gRPC statuses and back, so errors keep their chain,
+// stacks and attributes across gRPC calls.
+//
+// Each error of the chain is sent as a google.rpc.DebugInfo detail (message and stack entries),
+// and the attributes of the chain as a google.rpc.ErrorInfo detail.
+package grpcerr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/jjunac/betterr"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// Key of the attribute holding the status code of the errors received from a remote service.
+// It is used to propagate the code when the error is sent again, e.g. by a proxy.
+const CodeKey = "grpc.code"
+
+// Domain of the ErrorInfo details.
+const Domain = "github.com/jjunac/betterr"
+
+var (
+	registryMu sync.RWMutex
+	registry   []registeredCode
+)
+
+type registeredCode struct {
+	target error
+	code   codes.Code
+}
+
+// Registers the status code of the errors matching target, as defined by [betterr.Is].
+// Use it to give a code to the categories of errors of your domain, e.g. Register(ErrNotFound, codes.NotFound),
+// ErrNotFound being a sentinel error or a [betterr.ErrorKind].
+// The first registered target matching an error wins.
+func Register(target error, code codes.Code) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, registeredCode{target: target, code: code})
+}
+
+// Returns the status code of the error. In order of precedence, it is:
+//   - the code of an error of the chain implementing GRPCStatus() *status.Status
+//   - the code received from a remote service, see [CodeKey]
+//   - the code of the first registered target matching the error, see [Register]
+//   - Canceled or DeadlineExceeded for the context errors
+//   - Unknown otherwise
+func Code(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+	var grpcStatus interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &grpcStatus) {
+		return grpcStatus.GRPCStatus().Code()
+	}
+	for curr := err; curr != nil; {
+		betterErr, ok := curr.(*betterr.BetterError)
+		if !ok {
+			break
+		}
+		for _, attr := range betterErr.Attrs {
+			if code, ok := attr.Value.(codes.Code); ok && attr.Key == CodeKey {
+				return code
+			}
+		}
+		curr = betterErr.Wrapped
+	}
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for _, registered := range registry {
+		if betterr.Is(err, registered.target) {
+			return registered.code
+		}
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	}
+	return codes.Unknown
+}
+
+// Converts the error into a gRPC status.
+// The message of the status is the messages of the whole chain in Go style, and the details carry
+// a DebugInfo per error of the chain and an ErrorInfo with the attributes of the chain, after applying the [betterr.Redactors].
+// Errors already being a status, like the ones returned by status.Error, are returned as is.
+// A nil error gives an OK status.
+func ToStatus(err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+	if _, isBetterErr := err.(*betterr.BetterError); !isBetterErr {
+		if st, ok := status.FromError(err); ok {
+			return st
+		}
+	}
+	code := Code(err)
+	st := status.New(code, new(betterr.GoStyleFormatter).Format(err))
+
+	var details []protoadapt.MessageV1
+	metadata := map[string]string{}
+	for curr := err; curr != nil; {
+		if join, ok := curr.(interface{ Unwrap() []error }); ok && len(join.Unwrap()) == 1 {
+			// The chains received by the client interceptors are wrapped with their status
+			curr = join.Unwrap()[0]
+			continue
+		}
+		betterErr, ok := curr.(*betterr.BetterError)
+		if !ok {
+			details = append(details, &errdetails.DebugInfo{Detail: betterr.Redact(curr).Msg})
+			break
+		}
+		r := betterr.Redact(betterErr)
+		debugInfo := &errdetails.DebugInfo{Detail: r.Msg, StackEntries: make([]string, len(r.Frames))}
+		for i, frame := range r.Frames {
+			debugInfo.StackEntries[i] = formatStackEntry(frame)
+		}
+		details = append(details, debugInfo)
+		for _, attr := range r.Attrs {
+			if _, exists := metadata[attr.Key]; !exists && attr.Key != CodeKey {
+				metadata[attr.Key] = fmt.Sprint(attr.Value)
+			}
+		}
+		curr = betterErr.Wrapped
+	}
+	details = append(details, &errdetails.ErrorInfo{
+		Reason:   reason(code),
+		Domain:   Domain,
+		Metadata: metadata,
+	})
+
+	withDetails, detailsErr := st.WithDetails(details...)
+	if detailsErr != nil {
+		// Only happens for OK statuses, which errors cannot have
+		return st
+	}
+	return withDetails
+}
+
+// Converts a gRPC status back into an error chain.
+// Each DebugInfo detail gives a BetterError with a [betterr.StaticStacktrace], or a plain error if it has no stack entries.
+// The outermost error carries the attributes of the ErrorInfo detail as strings, and the status code (see [CodeKey]).
+// A status without DebugInfo, e.g. coming from a service not using this package, gives a single error
+// with the message of the status. An OK status gives nil.
+func FromStatus(st *status.Status) error {
+	if st == nil || st.Code() == codes.OK {
+		return nil
+	}
+	var layers []*errdetails.DebugInfo
+	var attrs []betterr.Attr
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.DebugInfo:
+			layers = append(layers, d)
+		case *errdetails.ErrorInfo:
+			if d.Domain == Domain {
+				keys := make([]string, 0, len(d.Metadata))
+				for key := range d.Metadata {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+				for _, key := range keys {
+					attrs = append(attrs, betterr.Attr{Key: key, Value: d.Metadata[key]})
+				}
+			}
+		}
+	}
+	attrs = append(attrs, betterr.Attr{Key: CodeKey, Value: st.Code()})
+	if len(layers) == 0 {
+		return &betterr.BetterError{Msg: st.Message(), Stack: &betterr.StaticStacktrace{}, Attrs: attrs}
+	}
+
+	var err error
+	for i := len(layers) - 1; i >= 0; i-- {
+		layer := layers[i]
+		if len(layer.StackEntries) == 0 && err == nil && i > 0 {
+			err = errors.New(layer.Detail)
+			continue
+		}
+		frames := make([]betterr.StackFrames, 0, len(layer.StackEntries))
+		for _, entry := range layer.StackEntries {
+			frames = append(frames, parseStackEntry(entry))
+		}
+		err = &betterr.BetterError{Msg: layer.Detail, Wrapped: err, Stack: &betterr.StaticStacktrace{Frames: frames}}
+	}
+	outermost := err.(*betterr.BetterError)
+	outermost.Attrs = attrs
+	return outermost
+}
+
+// Same as [FromStatus], for the errors returned by gRPC calls.
+// Errors that are not gRPC statuses are returned as is.
+func FromError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	return FromStatus(st)
+}
+
+// Stack entries use the same layout as the frames of [betterr.JavaStyleFormatter]: "function (file:line)".
+func formatStackEntry(frame betterr.StackFrames) string {
+	return frame.Function + " (" + frame.File + ":" + strconv.Itoa(frame.Line) + ")"
+}
+
+func parseStackEntry(entry string) betterr.StackFrames {
+	// Function names have no spaces, unlike file paths
+	open := strings.Index(entry, " (")
+	if open < 0 || !strings.HasSuffix(entry, ")") {
+		return betterr.StackFrames{Function: entry}
+	}
+	frame := betterr.StackFrames{Function: entry[:open]}
+	location := entry[open+2 : len(entry)-1]
+	if colon := strings.LastIndexByte(location, ':'); colon >= 0 {
+		if line, err := strconv.Atoi(location[colon+1:]); err == nil {
+			frame.File, frame.Line = location[:colon], line
+			return frame
+		}
+	}
+	frame.File = location
+	return frame
+}
+
+// Returns the code name in UPPER_SNAKE_CASE, as ErrorInfo reasons are expected to be.
+func reason(code codes.Code) string {
+	sb := strings.Builder{}
+	for i, r := range code.String() {
+		if unicode.IsUpper(r) && i > 0 {
+			sb.WriteByte('_')
+		}
+		sb.WriteRune(unicode.ToUpper(r))
+	}
+	return sb.String()
+}
@@ -0,0 +1,207 @@
+package grpcerr
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/jjunac/betterr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var errNotFound = betterr.New("not found")
+
+var errQuota = betterr.Kind[int]("quota exceeded")
+
+func init() {
+	Register(errNotFound, codes.NotFound)
+	Register(errQuota, codes.ResourceExhausted)
+}
+
+// Health service failing with the error it is configured with, used as a test service.
+type failingHealthServer struct {
+	healthpb.UnimplementedHealthServer
+	err error
+}
+
+func (s *failingHealthServer) Check(context.Context, *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	return nil, s.err
+}
+
+func (s *failingHealthServer) Watch(*healthpb.HealthCheckRequest, healthpb.Health_WatchServer) error {
+	return s.err
+}
+
+func newHealthClient(t *testing.T, serverErr error) healthpb.HealthClient {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryServerInterceptor()),
+		grpc.StreamInterceptor(StreamServerInterceptor()),
+	)
+	healthpb.RegisterHealthServer(server, &failingHealthServer{err: serverErr})
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(StreamClientInterceptor()),
+	)
+	if err != nil {
+		t.Fatalf("\nCannot connect: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func serverError() error {
+	return betterr.Decorate(
+		betterr.WithAttrs(betterr.Wrap(errors.New("no rows")), betterr.Attr{Key: "table", Value: "users"}),
+		"cannot load user")
+}
+
+func assertRemoteChain(t *testing.T, err error, expectedCode codes.Code) {
+	t.Helper()
+	local, ok := err.(*betterr.BetterError)
+	if !ok {
+		t.Fatalf("\nExpected a BetterError, got %T: %v", err, err)
+	}
+	if !strings.HasPrefix(local.Message(), "call to /grpc.health.v1.Health/") {
+		t.Errorf("\nUnexpected client message: %s", local.Message())
+	}
+	var remote *betterr.BetterError
+	if !errors.As(local.Wrapped, &remote) {
+		t.Fatalf("\nExpected the remote chain, got %T", local.Wrapped)
+	}
+	if msg := new(betterr.GoStyleFormatter).Format(remote); msg != "cannot load user: no rows" {
+		t.Errorf("\nUnexpected remote chain: %s", msg)
+	}
+	frames := remote.Stack.GetFrames()
+	if len(frames) == 0 || frames[0].Function != "github.com/jjunac/betterr/grpcerr.serverError" || !strings.HasSuffix(frames[0].File, "grpcerr_test.go") || frames[0].Line == 0 {
+		t.Errorf("\nExpected the server stack, got %+v", frames)
+	}
+	if cause, ok := remote.Wrapped.(*betterr.BetterError); !ok || len(cause.Stack.GetFrames()) == 0 {
+		t.Errorf("\nExpected the stack of the cause")
+	}
+	if Code(err) != expectedCode {
+		t.Errorf("\nExpected code %v, got %v", expectedCode, Code(err))
+	}
+	if st, ok := status.FromError(err); !ok || st.Code() != expectedCode {
+		t.Errorf("\nExpected a status of code %v, got %v", expectedCode, st)
+	}
+	if msg := new(betterr.GoStyleFormatter).Format(err); msg != local.Message()+": cannot load user: no rows" {
+		t.Errorf("\nUnexpected chain: %s", msg)
+	}
+	found := false
+	for _, attr := range remote.Attrs {
+		found = found || (attr.Key == "table" && attr.Value == "users")
+	}
+	if !found {
+		t.Errorf("\nExpected the attributes, got %v", remote.Attrs)
+	}
+}
+
+func TestUnaryInterceptors(t *testing.T) {
+	client := newHealthClient(t, serverError())
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assertRemoteChain(t, err, codes.Unknown)
+}
+
+func TestStreamInterceptors(t *testing.T) {
+	client := newHealthClient(t, serverError())
+	stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("\nUnexpected error: %v", err)
+	}
+	_, err = stream.Recv()
+	assertRemoteChain(t, err, codes.Unknown)
+}
+
+func TestInterceptors_RegisteredCode(t *testing.T) {
+	client := newHealthClient(t, betterr.Decorate(errNotFound, "cannot load user"))
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if Code(err) != codes.NotFound {
+		t.Errorf("\nExpected NotFound, got %v", Code(err))
+	}
+	if code := status.Code(err); code != codes.NotFound {
+		t.Errorf("\nExpected status.Code to give NotFound, got %v", code)
+	}
+	// The code is kept when the error is sent again
+	if code := ToStatus(betterr.Decorate(err, "proxy failed")).Code(); code != codes.NotFound {
+		t.Errorf("\nExpected NotFound, got %v", code)
+	}
+}
+
+func TestInterceptors_PlainStatus(t *testing.T) {
+	client := newHealthClient(t, status.Error(codes.PermissionDenied, "denied"))
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if Code(err) != codes.PermissionDenied {
+		t.Errorf("\nExpected PermissionDenied, got %v", Code(err))
+	}
+	if code := status.Code(err); code != codes.PermissionDenied {
+		t.Errorf("\nExpected status.Code to give PermissionDenied, got %v", code)
+	}
+	if msg := new(betterr.GoStyleFormatter).Format(err); msg != "call to /grpc.health.v1.Health/Check failed: denied" {
+		t.Errorf("\nUnexpected message: %s", msg)
+	}
+}
+
+func TestCode(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected codes.Code
+	}{
+		{nil, codes.OK},
+		{errors.New("plain"), codes.Unknown},
+		{betterr.Decorate(context.Canceled, "canceled"), codes.Canceled},
+		{betterr.Wrap(context.DeadlineExceeded), codes.DeadlineExceeded},
+		{betterr.Decorate(status.Error(codes.Unavailable, "down"), "call failed"), codes.Unavailable},
+		{betterr.Decoratef(errNotFound, "user %d", 42), codes.NotFound},
+		{betterr.Decorate(errQuota.New(100), "upload failed"), codes.ResourceExhausted},
+	}
+	for _, tc := range testCases {
+		if actual := Code(tc.err); actual != tc.expected {
+			t.Errorf("\n%v: expected %v, got %v", tc.err, tc.expected, actual)
+		}
+	}
+}
+
+func TestToStatus_RoundTrip(t *testing.T) {
+	err := serverError()
+	st := ToStatus(err)
+	if st.Message() != "cannot load user: no rows" {
+		t.Errorf("\nUnexpected message: %s", st.Message())
+	}
+	back := FromStatus(st)
+	if expected, actual := new(betterr.JavaStyleFormatter).Format(err), new(betterr.JavaStyleFormatter).Format(back); expected != actual {
+		t.Errorf("\nExpected:\n%s\nActual:\n%s", expected, actual)
+	}
+	if FromStatus(ToStatus(nil)) != nil {
+		t.Errorf("\nExpected nil for an OK status")
+	}
+}
+
+func TestParseStackEntry(t *testing.T) {
+	frame := parseStackEntry("github.com/myapp.(*T).Run (/src/my app (v2)/file.go:42)")
+	if frame.Function != "github.com/myapp.(*T).Run" || frame.File != "/src/my app (v2)/file.go" || frame.Line != 42 {
+		t.Errorf("\nUnexpected frame: %+v", frame)
+	}
+	frame = parseStackEntry("garbage")
+	if frame.Function != "garbage" {
+		t.Errorf("\nUnexpected frame: %+v", frame)
+	}
+}
+
+func TestReason(t *testing.T) {
+	if r := reason(codes.DeadlineExceeded); r != "DEADLINE_EXCEEDED" {
+		t.Errorf("\nUnexpected reason: %s", r)
+	}
+}
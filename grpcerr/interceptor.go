@@ -0,0 +1,105 @@
+package grpcerr
+
+import (
+	"context"
+	"io"
+
+	"github.com/jjunac/betterr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Returns a server interceptor converting the errors returned by unary handlers with [ToStatus].
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, ToStatus(err).Err()
+		}
+		return resp, nil
+	}
+}
+
+// Returns a server interceptor converting the errors returned by stream handlers with [ToStatus].
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, ss); err != nil {
+			return ToStatus(err).Err()
+		}
+		return nil
+	}
+}
+
+// Returns a client interceptor converting the errors of unary calls with [FromError].
+// The remote chain is decorated with the method name and the stack of the call,
+// and the status of the call is kept, so status.Code and status.FromError give its code.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+			return betterr.Decoratef(fromCallError(err), "call to %s failed", method)
+		}
+		return nil
+	}
+}
+
+// Returns a client interceptor converting the errors of streaming calls with [FromError].
+// The remote chain is decorated with the method name and the stack of the call,
+// and the status of the call is kept, so status.Code and status.FromError give its code.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, betterr.Decoratef(fromCallError(err), "call to %s failed", method)
+		}
+		return &clientStream{ClientStream: stream, method: method}, nil
+	}
+}
+
+type clientStream struct {
+	grpc.ClientStream
+	method string
+}
+
+func (s *clientStream) SendMsg(m any) error {
+	return s.convert(s.ClientStream.SendMsg(m))
+}
+
+func (s *clientStream) RecvMsg(m any) error {
+	return s.convert(s.ClientStream.RecvMsg(m))
+}
+
+// io.EOF marks the end of the stream and is returned as is, callers compare it with ==.
+func (s *clientStream) convert(err error) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+	return betterr.Decoratef(fromCallError(err), "call to %s failed", s.method)
+}
+
+// Converts the error of a call with [FromError], keeping the status it was converted from.
+func fromCallError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	return &statusError{err: FromStatus(st), status: st}
+}
+
+// Remote chain converted from a status, implementing GRPCStatus() like the errors of the status package.
+// It wraps the chain like a join of a single error (see errors.Join), so the formatters of betterr print the chain alone.
+type statusError struct {
+	err    error
+	status *status.Status
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() []error {
+	return []error{e.err}
+}
+
+func (e *statusError) GRPCStatus() *status.Status {
+	return e.status
+}
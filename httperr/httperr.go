@@ -0,0 +1,175 @@
+// Package httperr propagates BetterErrors between Go services over HTTP.
+//
+// The server encodes the error chain, in the format of [betterr.JsonFormatter], in the body or in a header of the response.
+// On the client side, [Transport] decodes it back into a chain whose remote part has [betterr.StaticStacktrace]s
+// and is marked with the name of the remote service, so the Java style shows "Caused by (remote service name): ...".
+package httperr
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/jjunac/betterr"
+)
+
+const (
+	// ContentType of the response bodies holding an encoded error.
+	ContentType = "application/vnd.betterr.error+json"
+	// ErrorHeader holds the encoded error, base64url encoded, when the body cannot be used.
+	ErrorHeader = "X-Betterr-Error"
+	// ServiceHeader holds the name of the service that produced the error.
+	ServiceHeader = "X-Betterr-Service"
+)
+
+// Options of the server side.
+type Options struct {
+	// Service is the name of the service, shown by the clients receiving its errors.
+	Service string
+	// RedactStacks removes the stacks from the encoded errors, for clients outside of the trust boundary.
+	RedactStacks bool
+	// UseHeader encodes the error in the [ErrorHeader] and leaves the body of the response empty,
+	// e.g. for the responses to HEAD requests, or for the clients expecting a body in another format.
+	// Headers are limited in size by most servers and proxies, so the body is preferred for large errors.
+	UseHeader bool
+	// StatusCode returns the status of the response for an error (default 500 Internal Server Error).
+	StatusCode func(err error) int
+}
+
+// Adapts a handler function returning an error. When it returns an error, it is written with [WriteError].
+// The function must not have written the status of the response yet.
+func Handler(options Options, fn func(w http.ResponseWriter, r *http.Request) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := fn(w, r); err != nil {
+			WriteError(w, err, options)
+		}
+	})
+}
+
+// Writes the error chain in the response, with the status returned by [Options.StatusCode].
+func WriteError(w http.ResponseWriter, err error, options Options) {
+	statusCode := http.StatusInternalServerError
+	if options.StatusCode != nil {
+		statusCode = options.StatusCode(err)
+	}
+	if options.RedactStacks {
+		err = redactStacks(err)
+	}
+	encoded := new(betterr.JsonFormatter).Format(err)
+
+	if options.Service != "" {
+		w.Header().Set(ServiceHeader, options.Service)
+	}
+	if options.UseHeader {
+		w.Header().Set(ErrorHeader, base64.RawURLEncoding.EncodeToString([]byte(encoded)))
+		w.WriteHeader(statusCode)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(statusCode)
+	_, _ = io.WriteString(w, encoded)
+}
+
+// Transport is an http.RoundTripper decoding the errors encoded by [WriteError].
+// When the response holds an error, its body is closed and RoundTrip returns the decoded chain,
+// decorated with the request. Note that http.Client wraps it in a *url.Error, use errors.As to get the chain back.
+type Transport struct {
+	// Base sends the requests (default http.DefaultTransport).
+	Base http.RoundTripper
+	// RedactStacks drops the stacks of the received errors, for servers outside of the trust boundary.
+	RedactStacks bool
+}
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+// Maximum number of bytes read from the body of an error response before closing it, larger bodies close the connection.
+const maxDrainedBody = 64 << 10
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	remote := FromResponse(resp)
+	if remote == nil {
+		return resp, nil
+	}
+	// The response is not returned along with the error, its body is drained so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainedBody))
+	resp.Body.Close()
+	if t.RedactStacks {
+		remote = redactStacks(remote)
+	}
+	return nil, betterr.Decoratef(remote, "%s %s failed with status %d", req.Method, req.URL, resp.StatusCode)
+}
+
+// Maximum size of an encoded error read from a response body, larger errors are rejected.
+// Deep chains with their stacks take a few tens of kilobytes, this leaves room without trusting the remote service.
+const maxErrorBody = 1 << 20
+
+// Decodes the error encoded by [WriteError] in the response, or returns nil if there is none.
+// When the error is in the body, the body is consumed, up to 1 MiB. The service is taken from the [ServiceHeader],
+// or the host of the request when it is missing.
+func FromResponse(resp *http.Response) error {
+	var data []byte
+	if header := resp.Header.Get(ErrorHeader); header != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(header)
+		if err != nil {
+			return betterr.Decorate(err, "invalid encoded error header")
+		}
+		data = decoded
+	} else if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == ContentType {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody+1))
+		if err != nil {
+			return betterr.Decorate(err, "cannot read encoded error")
+		}
+		if len(body) > maxErrorBody {
+			return betterr.Errorf("encoded error larger than %d bytes", maxErrorBody)
+		}
+		data = body
+	} else {
+		return nil
+	}
+
+	service := resp.Header.Get(ServiceHeader)
+	if service == "" && resp.Request != nil {
+		service = resp.Request.URL.Host
+	}
+	// The outermost layer is always a BetterError, to carry the name of the remote service
+	remote, err := betterr.ParseJSON(data)
+	if err != nil {
+		return betterr.Decorate(err, "invalid encoded error")
+	}
+	remote.Attrs = append(remote.Attrs, betterr.Attr{Key: betterr.RemoteServiceKey, Value: service})
+	return remote
+}
+
+// Returns a copy of the chain without stacks, the errors of the joins included.
+// The errors of other libraries whose stack the formatters show (see betterr.StackExtractors) are replaced by their message,
+// the other plain errors are kept as is.
+func redactStacks(err error) error {
+	if join, ok := err.(interface{ Unwrap() []error }); ok {
+		var errs []error
+		for _, joined := range join.Unwrap() {
+			errs = append(errs, redactStacks(joined))
+		}
+		return errors.Join(errs...)
+	}
+	betterErr, ok := err.(*betterr.BetterError)
+	if !ok {
+		if err != nil && len(betterr.Redact(err).Frames) > 0 {
+			return errors.New(err.Error())
+		}
+		return err
+	}
+	cp := *betterErr
+	cp.Stack = &betterr.StaticStacktrace{}
+	cp.Wrapped = redactStacks(betterErr.Wrapped)
+	return &cp
+}
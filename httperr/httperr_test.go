@@ -0,0 +1,206 @@
+package httperr
+
+import (
+	"encoding/base64"
+	"errors"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jjunac/betterr"
+)
+
+// There are no golden files in this package, the flag is accepted so that go test ./... -update runs
+var _ = flag.Bool("update", false, "no effect, this package has no golden files")
+
+func findUser() error {
+	return betterr.Decorate(
+		betterr.WithAttrs(betterr.Wrap(errors.New("no rows")), betterr.Attr{Key: "table", Value: "users"}),
+		"user not found")
+}
+
+func newServer(t *testing.T, options Options) *httptest.Server {
+	server := httptest.NewServer(Handler(options, func(w http.ResponseWriter, r *http.Request) error {
+		if r.URL.Path == "/ok" {
+			_, err := io.WriteString(w, "hello")
+			return err
+		}
+		return findUser()
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func get(t *testing.T, transport *Transport, url string) (*http.Response, error) {
+	t.Helper()
+	client := &http.Client{Transport: transport}
+	resp, err := client.Get(url)
+	if err != nil {
+		var remote *betterr.BetterError
+		if !errors.As(err, &remote) {
+			t.Fatalf("\nExpected a BetterError, got %T: %v", err, err)
+		}
+		return nil, remote
+	}
+	return resp, nil
+}
+
+func TestTransport_DecodesRemoteChain(t *testing.T) {
+	for _, useHeader := range []bool{false, true} {
+		server := newServer(t, Options{Service: "users", UseHeader: useHeader, StatusCode: func(error) int { return http.StatusNotFound }})
+		_, err := get(t, &Transport{}, server.URL+"/users/42")
+
+		local := err.(*betterr.BetterError)
+		if !strings.HasPrefix(local.Message(), "GET "+server.URL+"/users/42 failed with status 404") {
+			t.Errorf("\nUnexpected message: %s", local.Message())
+		}
+		java := new(betterr.JavaStyleFormatter).Format(err)
+		if !strings.Contains(java, "\nCaused by (remote service users): user not found\n    at github.com/jjunac/betterr/httperr.findUser (") {
+			t.Errorf("\nExpected the remote stack in:\n%s", java)
+		}
+		if !strings.Contains(java, "\nCaused by: no rows\n    at github.com/jjunac/betterr/httperr.findUser (") {
+			t.Errorf("\nExpected the stack of the remote cause in:\n%s", java)
+		}
+		if msg := new(betterr.GoStyleFormatter).Format(local.Wrapped); msg != "user not found: no rows" {
+			t.Errorf("\nUnexpected remote chain: %s", msg)
+		}
+		cause := local.Wrapped.(*betterr.BetterError).Wrapped.(*betterr.BetterError)
+		if len(cause.Attrs) != 1 || cause.Attrs[0].Value != "users" {
+			t.Errorf("\nExpected the remote attributes, got %v", cause.Attrs)
+		}
+	}
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Body recording whether it was read to the end and closed.
+type trackedBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *trackedBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestTransport_ClosesErrorBody(t *testing.T) {
+	body := &trackedBody{Reader: strings.NewReader("error page")}
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(new(betterr.JsonFormatter).Format(betterr.New("user not found"))))
+	transport := &Transport{Base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusNotFound, Header: http.Header{ErrorHeader: {encoded}}, Body: body, Request: req}, nil
+	})}
+	req, _ := http.NewRequest(http.MethodGet, "http://users/42", nil)
+	resp, err := transport.RoundTrip(req)
+	if resp != nil || err == nil {
+		t.Fatalf("\nExpected an error and no response, got %v and %v", resp, err)
+	}
+	if rest, _ := io.ReadAll(body.Reader); !body.closed || len(rest) > 0 {
+		t.Errorf("\nExpected the body to be drained and closed")
+	}
+}
+
+func TestTransport_SuccessfulResponse(t *testing.T) {
+	server := newServer(t, Options{Service: "users"})
+	resp, _ := get(t, &Transport{}, server.URL+"/ok")
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "hello" {
+		t.Errorf("\nUnexpected body: %s", body)
+	}
+}
+
+func TestRedactStacks(t *testing.T) {
+	testCases := []struct {
+		name      string
+		server    Options
+		transport *Transport
+	}{
+		{"server side", Options{Service: "users", RedactStacks: true}, &Transport{}},
+		{"client side", Options{Service: "users"}, &Transport{RedactStacks: true}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newServer(t, tc.server)
+			_, err := get(t, tc.transport, server.URL+"/users/42")
+			java := new(betterr.JavaStyleFormatter).Format(err.(*betterr.BetterError).Wrapped)
+			if java != "(remote service users): user not found\nCaused by: no rows\n" {
+				t.Errorf("\nExpected no remote stack, got:\n%s", java)
+			}
+		})
+	}
+}
+
+func TestRedactStacks_JoinedErrors(t *testing.T) {
+	err := betterr.Errorf("both failed: %w, %w", betterr.New("first"), betterr.New("second"))
+	java := new(betterr.JavaStyleFormatter).Format(redactStacks(err))
+	if java != "both failed\nCaused by: first\nCaused by: second\n" {
+		t.Errorf("\nExpected no stack, got:\n%s", java)
+	}
+}
+
+// Same types as github.com/pkg/errors.
+type pkgFrame uintptr
+type pkgStackTrace []pkgFrame
+
+type pkgError struct {
+	msg   string
+	stack pkgStackTrace
+}
+
+func (e *pkgError) Error() string             { return e.msg }
+func (e *pkgError) StackTrace() pkgStackTrace { return e.stack }
+
+func newPkgError(msg string) error {
+	pcs := make([]uintptr, 32)
+	err := &pkgError{msg: msg}
+	for _, pc := range pcs[:runtime.Callers(1, pcs)] {
+		err.stack = append(err.stack, pkgFrame(pc))
+	}
+	return err
+}
+
+func TestWriteError_RedactsForeignStacks(t *testing.T) {
+	err := betterr.Decorate(newPkgError("no rows"), "user not found")
+	if !strings.Contains(new(betterr.JsonFormatter).Format(err), "newPkgError") {
+		t.Fatalf("\nExpected the stack of the cause to be formatted")
+	}
+	recorder := httptest.NewRecorder()
+	WriteError(recorder, err, Options{RedactStacks: true})
+	if body := recorder.Body.String(); strings.Contains(body, `"stack"`) || !strings.Contains(body, `"message":"no rows"`) {
+		t.Errorf("\nExpected the messages without stack, got:\n%s", body)
+	}
+}
+
+func TestFromResponse_ServiceFallsBackToHost(t *testing.T) {
+	server := newServer(t, Options{})
+	_, err := get(t, &Transport{}, server.URL+"/users/42")
+	java := new(betterr.JavaStyleFormatter).Format(err)
+	if !strings.Contains(java, "Caused by (remote service "+strings.TrimPrefix(server.URL, "http://")+"): user not found") {
+		t.Errorf("\nExpected the host as service name in:\n%s", java)
+	}
+}
+
+func TestFromResponse_NoEncodedError(t *testing.T) {
+	resp := &http.Response{Header: http.Header{"Content-Type": {"application/json"}}, Body: io.NopCloser(strings.NewReader("{}"))}
+	if err := FromResponse(resp); err != nil {
+		t.Errorf("\nExpected no error, got %v", err)
+	}
+}
+
+func TestFromResponse_BodyTooLarge(t *testing.T) {
+	body := strings.Repeat(" ", maxErrorBody) + `{"message":"too late"}`
+	resp := &http.Response{Header: http.Header{"Content-Type": {ContentType}}, Body: io.NopCloser(strings.NewReader(body))}
+	err := FromResponse(resp)
+	if err == nil || !strings.Contains(err.Error(), "encoded error larger than 1048576 bytes") {
+		t.Errorf("\nExpected the body to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,212 @@
+package betterr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Extracts the stack trace of an error that is not a BetterError, e.g. an error of another library.
+// Returns nil when the error has no stack trace it knows of.
+type StackExtractor func(err error) Stacktrace
+
+// StackExtractors are tried, in order, to find the stack trace of the errors that are not BetterErrors,
+// so [StackOf] and the formatters show it instead of the message alone.
+// The default ones support the errors of github.com/pkg/errors, github.com/rotisserie/eris, github.com/joomcode/errorx
+// and github.com/go-errors/errors. Other libraries can be supported by adding an extractor, see [CallersExtractor].
+var StackExtractors = []StackExtractor{
+	// github.com/go-errors/errors
+	CallersExtractor(func(err interface{ Callers() []uintptr }) []uintptr { return err.Callers() }),
+	// github.com/rotisserie/eris
+	CallersExtractor(func(err interface{ StackFrames() []uintptr }) []uintptr { return err.StackFrames() }),
+	pkgErrorsStack,
+	errorxStack,
+}
+
+// Returns an extractor of the errors implementing T, whose program counters, as returned by runtime.Callers, are given by callers.
+// Example:
+//   betterr.StackExtractors = append(betterr.StackExtractors, betterr.CallersExtractor(func(err *mylib.Error) []uintptr {
+//       return err.PCs
+//   }))
+func CallersExtractor[T any](callers func(T) []uintptr) StackExtractor {
+	return func(err error) Stacktrace {
+		t, ok := err.(T)
+		if !ok {
+			return nil
+		}
+		return callersStack(callers(t))
+	}
+}
+
+func callersStack(pcs []uintptr) Stacktrace {
+	if len(pcs) == 0 {
+		return nil
+	}
+	return &RuntimeStacktrace{Stack: pcs}
+}
+
+// Returns the stack trace of the error found by the first of the [StackExtractors] supporting it, or nil.
+func extractStack(err error) Stacktrace {
+	for _, extractor := range StackExtractors {
+		if stack := extractor(err); stack != nil {
+			return stack
+		}
+	}
+	return nil
+}
+
+// Returns the deepest stack trace of the errors of the chain that are not BetterErrors, or nil.
+// The chain is followed until a BetterError or a join, e.g. through the layers of github.com/pkg/errors,
+// where the deepest stack trace is the one of the root cause.
+func foreignStack(err error) Stacktrace {
+	var stack Stacktrace
+	for curr := err; curr != nil; curr = errors.Unwrap(curr) {
+		if _, ok := curr.(*BetterError); ok {
+			break
+		}
+		if s := extractStack(curr); s != nil {
+			stack = s
+		}
+	}
+	return stack
+}
+
+// Extracts the stack trace of github.com/pkg/errors, returned by a StackTrace() errors.StackTrace method.
+// errors.StackTrace is a slice of program counters, which is matched by reflection to not depend on the library.
+func pkgErrorsStack(err error) Stacktrace {
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() {
+		return nil
+	}
+	methodType := method.Type()
+	if methodType.NumIn() != 0 || methodType.NumOut() != 1 ||
+		methodType.Out(0).Kind() != reflect.Slice || methodType.Out(0).Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+	return callersStack(uintptrs(method.Call(nil)[0]))
+}
+
+// Extracts the stack trace of github.com/joomcode/errorx, which keeps it in the pc field of the unexported stackTrace
+// of its errors, only read by reflection.
+func errorxStack(err error) Stacktrace {
+	v := reflect.ValueOf(err)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct || v.Elem().Type().PkgPath() != "github.com/joomcode/errorx" {
+		return nil
+	}
+	trace := v.Elem().FieldByName("stackTrace")
+	if trace.Kind() != reflect.Pointer || trace.IsNil() || trace.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	pcs := trace.Elem().FieldByName("pc")
+	if pcs.Kind() != reflect.Slice || pcs.Type().Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+	return callersStack(uintptrs(pcs))
+}
+
+// Copies a slice whose elements are uintptrs, whatever their type.
+func uintptrs(slice reflect.Value) []uintptr {
+	pcs := make([]uintptr, slice.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(slice.Index(i).Uint())
+	}
+	return pcs
+}
+
+// Frame is a program counter of a stack trace, as returned by runtime.Callers.
+// It is structurally compatible with the Frame of github.com/pkg/errors, and formatted the same way.
+type Frame uintptr
+
+// StackTrace is structurally compatible with the StackTrace of github.com/pkg/errors, so the tools reading the stacks
+// of its errors by reflection, like Sentry's Go SDK, read the ones of BetterErrors too.
+type StackTrace []Frame
+
+// Returns the stack trace of the error, like the errors of github.com/pkg/errors.
+// Returns nil when the stack has no program counters, e.g. when it was parsed (see [StaticStacktrace]).
+func (e *BetterError) StackTrace() StackTrace {
+	pcs := e.Callers()
+	if pcs == nil {
+		return nil
+	}
+	trace := make(StackTrace, len(pcs))
+	for i, pc := range pcs {
+		trace[i] = Frame(pc)
+	}
+	return trace
+}
+
+// Returns a copy of the program counters of the stack trace of the error, like the errors of github.com/go-errors/errors.
+// Returns nil when the stack has no program counters, e.g. when it was parsed (see [StaticStacktrace]).
+func (e *BetterError) Callers() []uintptr {
+	var pcs []uintptr
+	switch stack := e.Stack.(type) {
+	case *RuntimeStacktrace:
+		pcs = stack.Stack
+	case RuntimeStacktrace:
+		pcs = stack.Stack
+	default:
+		return nil
+	}
+	return append([]uintptr(nil), pcs...)
+}
+
+func (f Frame) location() (function, file string, line int) {
+	// The program counters are return addresses, the call is the instruction before
+	fn := runtime.FuncForPC(uintptr(f) - 1)
+	if fn == nil {
+		return "unknown", "unknown", 0
+	}
+	file, line = fn.FileLine(uintptr(f) - 1)
+	return fn.Name(), file, line
+}
+
+// Formats the frame like github.com/pkg/errors:
+//   %s    the base name of the file
+//   %d    the line
+//   %n    the name of the function, without its package
+//   %v    "file:line"
+//   %+s   the function and the path of the file, separated by "\n\t"
+//   %+v   "function\n\tpath:line"
+func (f Frame) Format(s fmt.State, verb rune) {
+	function, file, line := f.location()
+	switch verb {
+	case 's':
+		if s.Flag('+') {
+			io.WriteString(s, function+"\n\t"+file)
+		} else {
+			io.WriteString(s, path.Base(file))
+		}
+	case 'd':
+		io.WriteString(s, strconv.Itoa(line))
+	case 'n':
+		name := function[strings.LastIndexByte(function, '/')+1:]
+		io.WriteString(s, name[strings.IndexByte(name, '.')+1:])
+	case 'v':
+		f.Format(s, 's')
+		io.WriteString(s, ":")
+		f.Format(s, 'd')
+	}
+}
+
+// Formats the stack trace like github.com/pkg/errors: %+v prints every frame with %+v on its own line,
+// %v and %s print the list of the frames in brackets.
+func (st StackTrace) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			for _, f := range st {
+				io.WriteString(s, "\n")
+				f.Format(s, verb)
+			}
+			return
+		}
+		fmt.Fprintf(s, "%v", []Frame(st))
+	case 's':
+		fmt.Fprintf(s, "%s", []Frame(st))
+	}
+}
@@ -0,0 +1,171 @@
+package betterr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// Same types as github.com/pkg/errors.
+type pkgFrame uintptr
+type pkgStackTrace []pkgFrame
+
+type pkgError struct {
+	msg   string
+	stack []uintptr
+}
+
+func (e *pkgError) Error() string { return e.msg }
+func (e *pkgError) StackTrace() pkgStackTrace {
+	trace := make(pkgStackTrace, len(e.stack))
+	for i, pc := range e.stack {
+		trace[i] = pkgFrame(pc)
+	}
+	return trace
+}
+
+// Wraps an error with a message, like github.com/pkg/errors.WithMessage.
+type pkgMessage struct {
+	msg   string
+	cause error
+}
+
+func (e *pkgMessage) Error() string { return e.msg + ": " + e.cause.Error() }
+func (e *pkgMessage) Unwrap() error { return e.cause }
+
+// Same method as github.com/go-errors/errors.
+type callersError struct {
+	pcs []uintptr
+}
+
+func (e *callersError) Error() string     { return "callers error" }
+func (e *callersError) Callers() []uintptr { return e.pcs }
+
+type customError struct {
+	pcs []uintptr
+}
+
+func (e *customError) Error() string { return "custom error" }
+
+func callers() []uintptr {
+	pcs := make([]uintptr, 32)
+	return pcs[:runtime.Callers(2, pcs)]
+}
+
+func newPkgError(msg string) error {
+	return &pkgError{msg: msg, stack: callers()}
+}
+
+func TestStackExtractors(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+	}{
+		{"pkg/errors", newPkgError("failed")},
+		{"callers", &callersError{pcs: callers()}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stack := StackOf(tc.err)
+			assertTrue(t, stack != nil)
+			assertRegexp(t, `^github.com/jjunac/betterr\.(newPkgError|TestStackExtractors)$`, stack.GetFrames()[0].Function)
+		})
+	}
+	assertTrue(t, StackOf(&customError{pcs: callers()}) == nil)
+	assertTrue(t, StackOf(&pkgError{msg: "no stack"}) == nil)
+}
+
+func TestCallersExtractor(t *testing.T) {
+	previous := StackExtractors
+	defer func() {
+		StackExtractors = previous
+	}()
+	StackExtractors = append(StackExtractors, CallersExtractor(func(err *customError) []uintptr {
+		return err.pcs
+	}))
+
+	stack := StackOf(&customError{pcs: callers()})
+	assertTrue(t, stack != nil)
+	assertEqual(t, "github.com/jjunac/betterr.TestCallersExtractor", stack.GetFrames()[0].Function)
+}
+
+func TestStackOf_ForeignOrigin(t *testing.T) {
+	err := Decorate(&pkgMessage{msg: "query failed", cause: newPkgError("connection refused")}, "cannot load user")
+	assertEqual(t, "github.com/jjunac/betterr.newPkgError", StackOf(err).GetFrames()[0].Function)
+	// The origin is still the deepest BetterError
+	assertTrue(t, Origin(err) == err)
+}
+
+func TestFormatters_ForeignStack(t *testing.T) {
+	err := Decorate(&pkgMessage{msg: "query failed", cause: newPkgError("connection refused")}, "cannot load user")
+
+	java := new(JavaStyleFormatter).Format(err)
+	assertRegexp(t, `(?s)^cannot load user\n    at github.com/jjunac/betterr\.TestFormatters_ForeignStack .*`+
+		`\nCaused by: query failed: connection refused\n    at github.com/jjunac/betterr\.newPkgError \([^)]*interop_test\.go:\d+\)\n`, java)
+	parsed, parseErr := ParseJavaStyle(java)
+	assertNoError(t, parseErr)
+	assertEqual(t, "github.com/jjunac/betterr.newPkgError", StackOf(parsed).GetFrames()[0].Function)
+
+	var output struct {
+		Cause struct {
+			Message string
+			Stack   []StackFrames
+		}
+	}
+	assertNoError(t, json.Unmarshal([]byte(new(JsonFormatter).Format(err)), &output))
+	assertEqual(t, "query failed: connection refused", output.Cause.Message)
+	assertEqual(t, "github.com/jjunac/betterr.newPkgError", output.Cause.Stack[0].Function)
+
+	event := new(SentryFormatter).Event(err)
+	assertEqual(t, 2, len(event.Exception.Values))
+	assertTrue(t, event.Exception.Values[0].Stacktrace != nil)
+
+	assertTrue(t, strings.Contains(new(GCPErrorReportingFormatter).Format(err), "newPkgError"))
+	assertEqual(t, "github.com/jjunac/betterr.newPkgError", NewTemplateData(err).Cause.Frames[0].Function)
+
+	// Plain errors still end the chain without stack
+	assertEqual(t, "failed\nCaused by: EOF", new(JavaStyleFormatter).Format(&BetterError{Msg: "failed", Stack: &StaticStacktrace{}, Wrapped: io.EOF}))
+}
+
+func TestFingerprint_ForeignStack(t *testing.T) {
+	newErr := func(cause error) error {
+		return &BetterError{Msg: "cannot load user", Stack: &StaticStacktrace{}, Wrapped: cause}
+	}
+	first := newErr(newPkgError("connection refused"))
+	second := newErr(&pkgError{msg: "connection refused", stack: callers()})
+	assertEqual(t, Fingerprint(first), Fingerprint(newErr(newPkgError("connection refused"))))
+	assertTrue(t, Fingerprint(first) != Fingerprint(second))
+}
+
+func TestBetterError_StackTrace(t *testing.T) {
+	err := New("failed").(*BetterError)
+	pcs := err.Callers()
+	assertEqual(t, err.Stack.(*RuntimeStacktrace).Stack[0], pcs[0])
+
+	trace := err.StackTrace()
+	assertEqual(t, len(pcs), len(trace))
+	assertRegexp(t, `^interop_test\.go:\d+$`, fmt.Sprintf("%v", trace[0]))
+	assertRegexp(t, `^interop_test\.go$`, fmt.Sprintf("%s", trace[0]))
+	assertEqual(t, "TestBetterError_StackTrace", fmt.Sprintf("%n", trace[0]))
+	assertRegexp(t, `^github\.com/jjunac/betterr\.TestBetterError_StackTrace\n\t/.*/interop_test\.go:\d+$`, fmt.Sprintf("%+v", trace[0]))
+	assertRegexp(t, `^\[interop_test\.go:\d+ testing\.go:\d+`, fmt.Sprintf("%v", trace))
+	assertRegexp(t, `^\ngithub\.com/jjunac/betterr\.TestBetterError_StackTrace\n\t/.*/interop_test\.go:\d+\ntesting\.tRunner\n`, fmt.Sprintf("%+v", trace))
+
+	// The tools reading the stacks of github.com/pkg/errors by reflection read the same frames
+	assertEqual(t, fmt.Sprint(err.Stack.GetFrames()), fmt.Sprint(pkgErrorsStack(err).GetFrames()))
+	assertEqual(t, fmt.Sprint(err.Stack.GetFrames()), fmt.Sprint(CallersExtractor(func(err interface{ Callers() []uintptr }) []uintptr {
+		return err.Callers()
+	})(err).GetFrames()))
+	// The program counters are a copy, modifying them doesn't modify the stack of the error
+	pcs[0] = 0
+	assertTrue(t, err.Stack.(*RuntimeStacktrace).Stack[0] != 0)
+}
+
+func TestBetterError_StackTrace_Static(t *testing.T) {
+	err := &BetterError{Msg: "parsed", Stack: &StaticStacktrace{Frames: []StackFrames{{Function: "main.main", File: "main.go", Line: 1}}}}
+	assertTrue(t, err.StackTrace() == nil)
+	assertTrue(t, err.Callers() == nil)
+}
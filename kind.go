@@ -0,0 +1,74 @@
+package betterr
+
+// ErrorKind is a kind of errors of your domain carrying typed details, like an exception class in Java.
+// It is also an error, to be used as the target of [Is] and wherever sentinel errors are expected,
+// e.g. to register a status code with grpcerr.Register.
+// Example:
+//   type NotFoundDetails struct {
+//       Resource string
+//       ID       int
+//   }
+//   var ErrNotFound = betterr.Kind[NotFoundDetails]("not found")
+//
+//   err := ErrNotFound.New(NotFoundDetails{Resource: "user", ID: 42})
+//   if details, ok := betterr.AsKind(err, ErrNotFound); ok {
+//       fmt.Println(details.Resource)
+//   }
+type ErrorKind[T any] struct {
+	name string
+}
+
+// Creates a kind of errors whose details are of type T.
+// The name is the message of the errors of this kind.
+func Kind[T any](name string) *ErrorKind[T] {
+	return &ErrorKind[T]{name: name}
+}
+
+// Returns the name of the kind.
+func (k *ErrorKind[T]) Error() string {
+	return k.name
+}
+
+// Implemented by the ErrorKinds of any type of details.
+type errorKind interface {
+	isErrorKind()
+}
+
+func (k *ErrorKind[T]) isErrorKind() {}
+
+// Creates a new BetterError of this kind with the provided details.
+// The stack trace will start from the caller of this function.
+// Like [New], it takes a single allocation, plus one to store the details in the error for most types but pointers.
+func (k *ErrorKind[T]) New(details T) error {
+	err := newBetterError(1)
+	err.Msg = k.name
+	err.Kind = k
+	err.Details = details
+	return err
+}
+
+// Decorates the error in a BetterError of this kind with the provided details.
+// Decorating a nil error will return nil.
+func (k *ErrorKind[T]) Decorate(err error, details T) error {
+	if err == nil {
+		return nil
+	}
+	decorated := newBetterError(1)
+	decorated.Msg = k.name
+	decorated.Wrapped = err
+	decorated.Kind = k
+	decorated.Details = details
+	return decorated
+}
+
+// Returns the details of the first error of the kind in err's tree, and whether there is one.
+func AsKind[T any](err error, kind *ErrorKind[T]) (T, bool) {
+	for _, curr := range Chain(err) {
+		if betterErr, ok := curr.(*BetterError); ok && betterErr.Kind == error(kind) {
+			details, ok := betterErr.Details.(T)
+			return details, ok
+		}
+	}
+	var zero T
+	return zero, false
+}
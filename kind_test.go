@@ -0,0 +1,88 @@
+package betterr
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type notFoundDetails struct {
+	Resource string `json:"resource"`
+	ID       int    `json:"id"`
+}
+
+var errNotFound = Kind[notFoundDetails]("not found")
+
+var errConflict = Kind[string]("conflict")
+
+func TestKind_New(t *testing.T) {
+	err := errNotFound.New(notFoundDetails{Resource: "user", ID: 42})
+	assertEqual(t, "not found", new(GoStyleFormatter).Format(err))
+	assertEqual(t, "github.com/jjunac/betterr.TestKind_New", err.(*BetterError).Stack.GetFrames()[0].Function)
+
+	details, ok := AsKind(Decorate(err, "cannot load user"), errNotFound)
+	assertTrue(t, ok)
+	assertEqual(t, notFoundDetails{Resource: "user", ID: 42}, details)
+
+	_, ok = AsKind(err, errConflict)
+	assertFalse(t, ok)
+	_, ok = AsKind(io.EOF, errNotFound)
+	assertFalse(t, ok)
+}
+
+func TestKind_Is(t *testing.T) {
+	err := Decorate(errNotFound.New(notFoundDetails{}), "cannot load user")
+	assertTrue(t, Is(err, errNotFound))
+	assertTrue(t, errors.Is(err, errNotFound))
+	assertFalse(t, Is(err, errConflict))
+	assertFalse(t, Is(io.EOF, errNotFound))
+
+	// Kinds only match their errors, not the ones sharing their name
+	assertFalse(t, Is(New("not found"), errNotFound))
+	assertFalse(t, Is(errNotFound.New(notFoundDetails{}), Kind[int]("not found")))
+	assertFalse(t, Is(Decorate(errors.New("conflict"), "cannot save"), errConflict))
+}
+
+type redactorFunc func(r *Redaction)
+
+func (f redactorFunc) Redact(r *Redaction) {
+	f(r)
+}
+
+func TestKind_RedactedDetails(t *testing.T) {
+	withRedactors(t, &RegexpRedactor{Pattern: regexp.MustCompile(`v\d+`)}, redactorFunc(func(r *Redaction) {
+		if details, ok := r.Details.(notFoundDetails); ok {
+			details.Resource = RedactedPlaceholder
+			r.Details = details
+		}
+	}))
+	json := new(JsonFormatter).Format(Decorate(errNotFound.New(notFoundDetails{Resource: "user", ID: 42}), "cannot load"))
+	assertTrue(t, strings.Contains(json, `"details":{"resource":"[REDACTED]","id":42}`))
+	json = new(JsonFormatter).Format(errConflict.New("version v3"))
+	assertTrue(t, strings.Contains(json, `"details":"version [REDACTED]"`))
+}
+
+func TestKind_Decorate(t *testing.T) {
+	err := errConflict.Decorate(io.EOF, "version 3")
+	assertEqual(t, "conflict: EOF", new(GoStyleFormatter).Format(err))
+	details, ok := AsKind(err, errConflict)
+	assertTrue(t, ok)
+	assertEqual(t, "version 3", details)
+	assertTrue(t, errConflict.Decorate(nil, "") == nil)
+}
+
+func TestKind_JsonFormatter(t *testing.T) {
+	err := errNotFound.New(notFoundDetails{Resource: "user", ID: 42})
+	err.(*BetterError).Stack = &mockedStacktrace{}
+	assertJSONEq(t,
+		`{"fingerprint": "`+Fingerprint(err)+`", "message": "not found", "kind": "not found", "details": {"resource": "user", "id": 42}}`,
+		new(JsonFormatter).Format(err))
+}
+
+func TestKind_SentryExceptionType(t *testing.T) {
+	event := new(SentryFormatter).Event(Decorate(errNotFound.New(notFoundDetails{}), "cannot load user"))
+	assertEqual(t, "not found", event.Exception.Values[0].Type)
+	assertTrue(t, strings.HasPrefix(event.Exception.Values[1].Type, "*betterr."))
+}
@@ -0,0 +1,281 @@
+package betterr
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Parses text produced by [GoStyleFormatter] back into an error chain.
+// The Go style doesn't separate the messages from the ones of their causes, so the text is split at every ": ",
+// giving BetterErrors without stack for every layer but the last one, which is a plain Go error.
+func ParseGoStyle(text string) error {
+	layers := strings.Split(text, ": ")
+	var parsed error = errors.New(layers[len(layers)-1])
+	for i := len(layers) - 2; i >= 0; i-- {
+		parsed = &BetterError{Msg: layers[i], Wrapped: parsed, Stack: &StaticStacktrace{}}
+	}
+	return parsed
+}
+
+const (
+	javaFramePrefix    = "    at "
+	javaCausedBy       = "Caused by: "
+	javaRemotePrefix   = "(remote service "
+	javaRemoteCausedBy = "Caused by " + javaRemotePrefix
+)
+
+// Parses text produced by [JavaStyleFormatter] back into an error chain.
+// Every layer followed by a newline becomes a BetterError holding its frames in a [StaticStacktrace],
+// the last layer not followed by a newline is a plain Go error, and the layers received from a remote service
+// get the [RemoteServiceKey] attribute.
+// The files of the frames are kept as they are in the text, so parsing the output of a formatter using another [PathStyle]
+// gives frames whose File is in that style.
+// The text doesn't tell the errors of a join (see errors.Join) from a chain, so they are parsed as a chain.
+// Like with [ParsePanic], the outermost layer is always a BetterError, even when it is not followed by a newline.
+func ParseJavaStyle(text string) (*BetterError, error) {
+	type layer struct {
+		msg     []string
+		service string
+		frames  []StackFrames
+		plain   bool
+	}
+	var layers []*layer
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		last := i == len(lines)-1
+		if last && line == "" {
+			break
+		}
+		switch {
+		case len(layers) > 0 && strings.HasPrefix(line, javaFramePrefix):
+			frame, err := parseJavaFrame(line[len(javaFramePrefix):])
+			if err != nil {
+				return nil, Decoratef(err, "line %d", i+1)
+			}
+			curr := layers[len(layers)-1]
+			curr.frames = append(curr.frames, frame)
+		case len(layers) == 0 || strings.HasPrefix(line, javaCausedBy) || strings.HasPrefix(line, javaRemoteCausedBy):
+			header := line
+			if len(layers) > 0 {
+				header = strings.TrimPrefix(strings.TrimPrefix(header, javaCausedBy), "Caused by ")
+			}
+			curr := &layer{}
+			if strings.HasPrefix(header, javaRemotePrefix) {
+				if end := strings.Index(header, "): "); end >= 0 {
+					curr.service = header[len(javaRemotePrefix):end]
+					header = header[end+len("): "):]
+				}
+			}
+			curr.msg = []string{header}
+			curr.plain = last
+			layers = append(layers, curr)
+		default:
+			// Messages can span several lines, e.g. the ones of the errors of other libraries
+			curr := layers[len(layers)-1]
+			if len(curr.frames) > 0 {
+				return nil, Errorf("line %d: unexpected line after the stack trace: %q", i+1, line)
+			}
+			curr.msg = append(curr.msg, line)
+			curr.plain = last
+		}
+	}
+	if len(layers) == 0 {
+		return nil, New("no error to parse")
+	}
+	var wrapped error
+	var parsed *BetterError
+	for i := len(layers) - 1; i >= 0; i-- {
+		l := layers[i]
+		msg := strings.Join(l.msg, "\n")
+		if l.plain && wrapped == nil && l.service == "" && i > 0 {
+			wrapped = errors.New(msg)
+			continue
+		}
+		parsed = &BetterError{Msg: msg, Wrapped: wrapped, Stack: &StaticStacktrace{Frames: l.frames}}
+		if l.service != "" {
+			parsed.Attrs = []Attr{{Key: RemoteServiceKey, Value: l.service}}
+		}
+		wrapped = parsed
+	}
+	return parsed, nil
+}
+
+// Parses "function (file:line)".
+func parseJavaFrame(s string) (StackFrames, error) {
+	// Function names have no spaces, unlike files
+	open := strings.Index(s, " (")
+	if open < 0 || !strings.HasSuffix(s, ")") {
+		return StackFrames{}, Errorf("invalid frame %q", s)
+	}
+	return parseFrame(s[:open], s[open+2:len(s)-1])
+}
+
+// Builds the frame of the function at the location "file:line".
+func parseFrame(function, location string) (StackFrames, error) {
+	colon := strings.LastIndexByte(location, ':')
+	if colon < 0 {
+		return StackFrames{}, Errorf("invalid location %q", location)
+	}
+	line, err := strconv.Atoi(location[colon+1:])
+	if err != nil {
+		return StackFrames{}, Errorf("invalid line in %q", location)
+	}
+	if function == "" {
+		return StackFrames{}, Errorf("missing function of the frame at %q", location)
+	}
+	frame := StackFrames{Function: function, File: location[:colon], Line: line}
+	frame.Package, _ = SplitFunctionName(function)
+	parseFunctionName(&frame)
+	return frame, nil
+}
+
+// Line replacing the middle frames of the goroutines whose stack is too deep to be printed entirely.
+const panicFramesElided = "...additional frames elided..."
+
+// Parses the output of a Go panic, or the stack_trace of [GCPErrorReportingFormatter], into a BetterError.
+// The message is the value of the panic, and the frames are the ones of the first goroutine.
+// The arguments of the functions and the program counter offsets are ignored, and so are the frames elided by the runtime for deep stacks.
+func ParsePanic(text string) (*BetterError, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	start := -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, "panic: ") {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return nil, New(`no "panic: " line found`)
+	}
+	// The message runs until the blank line preceding the goroutine
+	var msg []string
+	i := start
+	for ; i < len(lines) && lines[i] != ""; i++ {
+		// Skip the description of the signal of runtime errors, e.g. "[signal SIGSEGV: segmentation violation ...]"
+		if !strings.HasPrefix(lines[i], "[signal ") {
+			msg = append(msg, lines[i])
+		}
+	}
+	msg[0] = strings.TrimSuffix(strings.TrimPrefix(msg[0], "panic: "), " [recovered]")
+	for i < len(lines) && !strings.HasPrefix(lines[i], "goroutine ") {
+		i++
+	}
+	var frames []StackFrames
+	for i++; i+1 < len(lines) && lines[i] != ""; i += 2 {
+		if lines[i] == panicFramesElided {
+			// Unlike the frames, made of a function line and a location line, it stands alone
+			i--
+			continue
+		}
+		function := strings.TrimPrefix(lines[i], "created by ")
+		if in := strings.Index(function, " in goroutine "); in >= 0 {
+			function = function[:in]
+		} else if open := strings.LastIndexByte(function, '('); open > 0 && strings.HasSuffix(function, ")") {
+			function = function[:open]
+		}
+		location := strings.TrimPrefix(lines[i+1], "\t")
+		if offset := strings.LastIndex(location, " +0x"); offset >= 0 {
+			location = location[:offset]
+		}
+		frame, err := parseFrame(function, location)
+		if err != nil {
+			return nil, Decoratef(err, "line %d", i+2)
+		}
+		frames = append(frames, frame)
+	}
+	return &BetterError{Msg: strings.Join(msg, "\n"), Stack: &StaticStacktrace{Frames: frames}}, nil
+}
+
+// Layer of the JSON produced by [JsonFormatter].
+type jsonLayer struct {
+	Message    string         `json:"message"`
+	Stack      []StackFrames  `json:"stack"`
+	Attributes map[string]any `json:"attributes"`
+	Cause      *jsonLayer     `json:"cause"`
+	Causes     []*jsonLayer   `json:"causes"`
+}
+
+// Parses JSON produced by [JsonFormatter] back into an error chain.
+// The layers become BetterErrors holding their frames in a [StaticStacktrace] and their attributes sorted by key.
+// The innermost layers become plain Go errors when they have neither stack nor attributes, as they were most likely ones,
+// and the layers with several causes but neither stack nor attributes become joins (see errors.Join).
+// The message of the layers is kept as is, their template, arguments and kind are not restored.
+// Like with [ParsePanic], the outermost layer is always a BetterError, wrapping the errors of the join when it is one.
+func ParseJSON(data []byte) (*BetterError, error) {
+	root := &jsonLayer{}
+	if err := json.Unmarshal(data, root); err != nil {
+		return nil, Decorate(err, "invalid JSON error")
+	}
+	parsed, err := root.parse()
+	if err != nil {
+		return nil, Decorate(err, "invalid JSON error")
+	}
+	if betterErr, ok := parsed.(*BetterError); ok {
+		return betterErr, nil
+	}
+	betterErr := &BetterError{Msg: root.Message, Stack: &StaticStacktrace{}}
+	if len(root.Causes) > 1 {
+		betterErr.Wrapped = parsed
+	}
+	return betterErr, nil
+}
+
+// Returns the error parsed from the layer, or an error if one of its frames is invalid.
+func (l *jsonLayer) parse() (parsed error, err error) {
+	var wrapped error
+	if l.Cause != nil {
+		if wrapped, err = l.Cause.parse(); err != nil {
+			return nil, err
+		}
+	} else if len(l.Causes) > 0 {
+		causes := make([]error, len(l.Causes))
+		for i, cause := range l.Causes {
+			if cause == nil {
+				return nil, Errorf("null cause %d", i)
+			}
+			if causes[i], err = cause.parse(); err != nil {
+				return nil, err
+			}
+		}
+		wrapped = errors.Join(causes...)
+	}
+	for i := range l.Stack {
+		if l.Stack[i].Function == "" {
+			return nil, Errorf("missing function in frame %d of %q", i, l.Message)
+		}
+		parseFunctionName(&l.Stack[i])
+	}
+	if len(l.Stack) == 0 && len(l.Attributes) == 0 {
+		if wrapped == nil {
+			return errors.New(l.Message), nil
+		}
+		if len(l.Causes) > 1 {
+			return wrapped, nil
+		}
+	}
+	return &BetterError{
+		Msg:     l.Message,
+		Wrapped: wrapped,
+		Stack:   &StaticStacktrace{Frames: l.Stack},
+		Attrs:   parseJSONAttrs(l.Attributes),
+	}, nil
+}
+
+func parseJSONAttrs(attributes map[string]any) []Attr {
+	if len(attributes) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	attrs := make([]Attr, len(keys))
+	for i, key := range keys {
+		attrs[i] = Attr{Key: key, Value: attributes[key]}
+	}
+	return attrs
+}
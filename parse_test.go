@@ -0,0 +1,239 @@
+package betterr
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func parseTestChains() []error {
+	remote := &BetterError{
+		Msg:     "user not found",
+		Stack:   &StaticStacktrace{Frames: []StackFrames{{Function: "github.com/users.(*Repo).Find", File: "/src/users/repo.go", Line: 12}}},
+		Attrs:   []Attr{{Key: RemoteServiceKey, Value: "users"}},
+		Wrapped: errors.New("no rows"),
+	}
+	return []error{
+		errors.New("A plain Go error"),
+		mockedChain(),
+		method_1deep(),
+		Decorate(errors.Join(errors.New("first"), errors.New("second")), "both failed"),
+		&BetterError{Msg: "without stack", Stack: &StaticStacktrace{}, Wrapped: New("multi\nline")},
+		&BetterError{Msg: "call failed", Stack: &StaticStacktrace{Frames: []StackFrames{{Function: "main.main", File: "/my app/main.go", Line: 3}}}, Wrapped: remote},
+		remote,
+	}
+}
+
+func TestParseJavaStyle_RoundTrip(t *testing.T) {
+	for _, formatter := range []*JavaStyleFormatter{{}, {Paths: ModulePath}} {
+		for _, err := range parseTestChains() {
+			expected := formatter.Format(err)
+			parsed, parseErr := ParseJavaStyle(expected)
+			assertNoError(t, parseErr)
+			if _, ok := err.(*BetterError); !ok {
+				// The outermost layer is parsed as a BetterError, followed by a newline
+				expected += "\n"
+			}
+			assertEqual(t, expected, formatter.Format(parsed))
+			if !hasJoin(err) {
+				assertEqual(t, new(GoStyleFormatter).Format(err), new(GoStyleFormatter).Format(parsed))
+			}
+		}
+	}
+}
+
+func hasJoin(err error) bool {
+	for _, layer := range Chain(err) {
+		if joinMembers(layer) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func TestParseJavaStyle(t *testing.T) {
+	parsed, err := ParseJavaStyle(new(JavaStyleFormatter).Format(mockedChain()))
+	assertNoError(t, err)
+	chain := Chain(parsed)
+	assertEqual(t, 3, len(chain))
+	frame := chain[1].(*BetterError).Stack.GetFrames()[0]
+	assertEqual(t, StackFrames{Function: "github.com/myapp.(*Worker).Run", File: "/src/myapp/file.go", Line: 42,
+		Package: "github.com/myapp", Receiver: "*Worker", Name: "Run"}, frame)
+	_, plain := chain[2].(*BetterError)
+	assertFalse(t, plain)
+
+	parsed, err = ParseJavaStyle("single line")
+	assertNoError(t, err)
+	assertEqual(t, "single line", parsed.Message())
+	assertTrue(t, parsed.Wrapped == nil)
+
+	_, err = ParseJavaStyle("msg\n    at main.main (main.go)\n")
+	assertTrue(t, err != nil)
+	_, err = ParseJavaStyle("msg\n    at main.main (main.go:1)\nunexpected")
+	assertTrue(t, err != nil)
+	_, err = ParseJavaStyle("")
+	assertTrue(t, err != nil)
+}
+
+func TestParseJavaStyle_InvalidFrames(t *testing.T) {
+	for _, text := range []string{
+		"msg\n    at main.main (main.go)\n",
+		"msg\n    at main.main (main.go:x)\n",
+		"msg\n    at main.main main.go:1\n",
+		"msg\n    at  (a.go:1)\n",
+		"msg\n    at  (:1)\n",
+	} {
+		_, err := ParseJavaStyle(text)
+		assertTrue(t, err != nil)
+	}
+	// Malformed function names are kept as is
+	parsed, err := ParseJavaStyle("msg\n    at pkg. (a.go:1)\n")
+	assertNoError(t, err)
+	assertEqual(t, "pkg.", parsed.Stack.GetFrames()[0].Name)
+}
+
+// Also run with go test -fuzz FuzzParseJavaStyle
+func FuzzParseJavaStyle(f *testing.F) {
+	for _, err := range parseTestChains() {
+		f.Add(new(JavaStyleFormatter).Format(err))
+	}
+	f.Add("x\n    at  (a.go:1)\n")
+	f.Add("x\n    at pkg. (a.go:1)\n")
+	f.Add("Caused by (remote service ): \n    at ")
+	f.Fuzz(func(t *testing.T, text string) {
+		parsed, err := ParseJavaStyle(text)
+		if err != nil {
+			return
+		}
+		for _, formatter := range allFormatters {
+			formatter.Format(parsed)
+		}
+	})
+}
+
+func TestParseGoStyle_RoundTrip(t *testing.T) {
+	for _, err := range parseTestChains() {
+		expected := new(GoStyleFormatter).Format(err)
+		assertEqual(t, expected, new(GoStyleFormatter).Format(ParseGoStyle(expected)))
+	}
+	assertEqual(t, 3, len(Chain(ParseGoStyle("a: b: c"))))
+}
+
+func TestParsePanic_RoundTrip(t *testing.T) {
+	for _, err := range parseTestChains() {
+		var expected gcpReportedErrorEvent
+		assertNoError(t, json.Unmarshal([]byte(new(GCPErrorReportingFormatter).Format(err)), &expected))
+		if expected.StackTrace == "" {
+			continue
+		}
+		parsed, parseErr := ParsePanic(expected.StackTrace)
+		assertNoError(t, parseErr)
+		var actual gcpReportedErrorEvent
+		assertNoError(t, json.Unmarshal([]byte(new(GCPErrorReportingFormatter).Format(parsed)), &actual))
+		assertEqual(t, expected.StackTrace, actual.StackTrace)
+	}
+}
+
+func TestParsePanic(t *testing.T) {
+	output := "some logs\n" +
+		"panic: runtime error: invalid memory address or nil pointer dereference [recovered]\n" +
+		"[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x4a2b1c]\n" +
+		"\n" +
+		"goroutine 7 [running]:\n" +
+		"github.com/myapp.(*Worker).Run(0xc000010000, {0x5b2e40, 0x1})\n" +
+		"\t/src/myapp/worker.go:42 +0x1c\n" +
+		"created by github.com/myapp.Start in goroutine 1\n" +
+		"\t/src/myapp/start.go:10 +0x5a\n" +
+		"\n" +
+		"goroutine 1 [chan receive]:\n" +
+		"main.main()\n" +
+		"\t/src/myapp/main.go:5 +0x10\n" +
+		"exit status 2\n"
+	parsed, err := ParsePanic(output)
+	assertNoError(t, err)
+	assertEqual(t, "runtime error: invalid memory address or nil pointer dereference", parsed.Message())
+	frames := parsed.Stack.GetFrames()
+	assertEqual(t, 2, len(frames))
+	assertEqual(t, StackFrames{Function: "github.com/myapp.(*Worker).Run", File: "/src/myapp/worker.go", Line: 42,
+		Package: "github.com/myapp", Receiver: "*Worker", Name: "Run"}, frames[0])
+	assertEqual(t, "github.com/myapp.Start", frames[1].Function)
+	assertEqual(t, 10, frames[1].Line)
+
+	elided := "panic: too deep\n\n" +
+		"goroutine 1 [running]:\n" +
+		"main.recurse(0x64)\n" +
+		"\t/src/myapp/main.go:8 +0x1c\n" +
+		"...additional frames elided...\n" +
+		"main.main()\n" +
+		"\t/src/myapp/main.go:12 +0x10\n"
+	parsed, err = ParsePanic(elided)
+	assertNoError(t, err)
+	frames = parsed.Stack.GetFrames()
+	assertEqual(t, 2, len(frames))
+	assertEqual(t, "main.recurse", frames[0].Function)
+	assertEqual(t, "main.main", frames[1].Function)
+	assertEqual(t, 12, frames[1].Line)
+
+	_, err = ParsePanic("no panic here")
+	assertTrue(t, err != nil)
+}
+
+func TestParseJSON_RoundTrip(t *testing.T) {
+	for _, err := range parseTestChains() {
+		expected := new(JsonFormatter).Format(err)
+		parsed, parseErr := ParseJSON([]byte(expected))
+		assertNoError(t, parseErr)
+		assertEqual(t, expected, new(JsonFormatter).Format(parsed))
+	}
+	_, err := ParseJSON([]byte("not json"))
+	assertTrue(t, err != nil)
+}
+
+func TestParseJSON_InvalidFrames(t *testing.T) {
+	for _, data := range []string{
+		`{"message":"x","stack":[{"function":"","file":"a.go","line":1}]}`,
+		`{"message":"x","stack":[{"file":"a.go","line":1}]}`,
+		`{"message":"x","stack":[null]}`,
+		`{"message":"x","cause":{"message":"y","stack":[{"function":""}]}}`,
+		`{"message":"x","causes":[{"message":"y"},null]}`,
+		`{"message":"x","stack":[{"function":"main.main","line":"1"}]}`,
+	} {
+		_, err := ParseJSON([]byte(data))
+		assertTrue(t, err != nil)
+	}
+	// Malformed function names are kept as is
+	parsed, err := ParseJSON([]byte(`{"message":"x","stack":[{"function":"pkg.","file":"a.go","line":1}]}`))
+	assertNoError(t, err)
+	assertEqual(t, "pkg.", parsed.Stack.GetFrames()[0].Name)
+}
+
+// Also run with go test -fuzz FuzzParseJSON
+func FuzzParseJSON(f *testing.F) {
+	for _, err := range parseTestChains() {
+		f.Add([]byte(new(JsonFormatter).Format(err)))
+	}
+	f.Add([]byte(`{"message":"x","stack":[{"function":"","file":"a.go","line":1}]}`))
+	f.Add([]byte(`{"message":"x","stack":[{"function":"pkg.","file":"a.go","line":1}]}`))
+	f.Add([]byte(`{"message":"x","causes":[{"message":"y"},null]}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		parsed, err := ParseJSON(data)
+		if err != nil {
+			return
+		}
+		for _, formatter := range allFormatters {
+			formatter.Format(parsed)
+		}
+	})
+}
+
+func TestParseJSON_OutermostJoin(t *testing.T) {
+	join := errors.Join(New("first"), errors.New("second"))
+	parsed, err := ParseJSON([]byte(new(JsonFormatter).Format(join)))
+	assertNoError(t, err)
+	assertEqual(t, "[first; second]", parsed.Message())
+	assertEqual(t, new(JavaStyleFormatter).Format(join), new(JavaStyleFormatter).Format(parsed))
+
+	parsed, err = ParseJSON([]byte(new(JsonFormatter).Format(errors.New("plain"))))
+	assertNoError(t, err)
+	assertEqual(t, "plain", parsed.Message())
+}
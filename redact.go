@@ -0,0 +1,280 @@
+package betterr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Placeholder replacing the redacted content.
+const RedactedPlaceholder = "[REDACTED]"
+
+// Redactors are applied, in order, to every error formatted by the formatters of the library.
+// You can add redactors to this variable to remove sensitive data from the formatted errors.
+// By default, there is none, but the values of attributes created with [SecretAttr] are always redacted.
+var Redactors []Redactor
+
+// Interface to remove sensitive data from errors before they are formatted.
+// Implement this interface to create custom redactors.
+// The library provides the following redactors:
+// - [RegexpRedactor]
+// - [AttrKeyRedactor]
+// - [TrimPathRedactor]
+type Redactor interface {
+	// Redact modifies the content of an error of the chain in place.
+	Redact(r *Redaction)
+}
+
+// Redaction is the content of an error of the chain, as the formatters output it.
+// Frames, Attrs and Args are copies, redactors can modify them freely.
+type Redaction struct {
+	Msg    string
+	Frames []StackFrames
+	Attrs  []Attr
+	// Template and Args are the format string and arguments the message was formatted with, see [BetterError.Template].
+	Template string
+	Args     []any
+	// Details are the details of the [ErrorKind] of the error. They are not copied: redactors replace the value
+	// instead of modifying it, e.g. with a copy of a struct whose sensitive fields are cleared.
+	Details any
+}
+
+// Returns the content of the error after applying the [Redactors].
+// Only the error itself is redacted, not its causes. For errors that are not BetterErrors, only Msg is set,
+// along with the Frames of their stack trace when the [StackExtractors] find one.
+// For joins (see errors.Join), Msg is the redacted messages of their errors, as printed by [GoStyleFormatter].
+// Custom formatters should use it instead of reading the fields of BetterError directly.
+func Redact(err error) Redaction {
+	betterr, ok := err.(*BetterError)
+	if !ok && joinMembers(err) != nil {
+		// The message of a join holds the whole text of its errors, their stack traces included, the one of the
+		// GoStyleFormatter is made of their redacted messages
+		return Redaction{Msg: new(GoStyleFormatter).Format(err)}
+	}
+	if !ok {
+		r := Redaction{Msg: err.Error()}
+		if stack := foreignStack(err); stack != nil {
+			r.Frames = stack.GetFrames()
+		}
+		for _, redactor := range Redactors {
+			redactor.Redact(&r)
+		}
+		return r
+	}
+	r := Redaction{
+		Msg:      betterr.Message(),
+		Frames:   betterr.Stack.GetFrames(),
+		Attrs:    betterr.Attrs,
+		Template: betterr.Template,
+		Args:     betterr.Args,
+		Details:  betterr.Details,
+	}
+	if len(Redactors) == 0 && !hasSecret(r.Attrs) && !hasSecretArg(r.Args) {
+		return r
+	}
+	r.Frames = append([]StackFrames(nil), r.Frames...)
+	r.Attrs = append([]Attr(nil), r.Attrs...)
+	r.Args = append([]any(nil), r.Args...)
+	for i, attr := range r.Attrs {
+		if _, ok := attr.Value.(secret); ok {
+			r.Attrs[i].Value = RedactedPlaceholder
+		}
+	}
+	for i, arg := range r.Args {
+		if _, ok := arg.(secret); ok {
+			r.Args[i] = RedactedPlaceholder
+		}
+	}
+	for _, redactor := range Redactors {
+		redactor.Redact(&r)
+	}
+	return r
+}
+
+// Applies the [Redactors] to a message alone, for formatters that don't output frames and attributes.
+func redactMsg(msg string) string {
+	if len(Redactors) == 0 {
+		return msg
+	}
+	r := Redaction{Msg: msg}
+	for _, redactor := range Redactors {
+		redactor.Redact(&r)
+	}
+	return r.Msg
+}
+
+func hasSecret(attrs []Attr) bool {
+	for _, attr := range attrs {
+		if _, ok := attr.Value.(secret); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func hasSecretArg(args []any) bool {
+	for _, arg := range args {
+		if _, ok := arg.(secret); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Creates an attribute whose value is never output by the formatters.
+// The value is replaced by [RedactedPlaceholder], including when it is printed with fmt or marshalled in JSON.
+func SecretAttr(key string, value any) Attr {
+	return Attr{Key: key, Value: secret{value: value}}
+}
+
+// Marks an argument of [Errorf] or [Decoratef] as secret, it is printed as [RedactedPlaceholder] whatever the verb.
+// Example:
+//   betterr.Errorf("cannot login as %s with password %s", user, betterr.Secret(password))
+func Secret(value any) any {
+	return secret{value: value}
+}
+
+// Holds the value of a secret attribute or argument, hiding it from fmt and encoding/json.
+type secret struct {
+	value any
+}
+
+func (s secret) String() string {
+	return RedactedPlaceholder
+}
+
+func (s secret) Format(f fmt.State, verb rune) {
+	io.WriteString(f, RedactedPlaceholder)
+}
+
+func (s secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(RedactedPlaceholder)
+}
+
+// Replaces the matches of a regexp in the messages and templates, and in the values of the attributes, arguments and details.
+// The values that are not strings, e.g. structs, are matched on their text as printed by fmt, and replaced by the redacted text.
+// Example, to redact email addresses:
+//   betterr.Redactors = append(betterr.Redactors, &betterr.RegexpRedactor{
+//       Pattern: regexp.MustCompile(`[\w.+-]+@[\w-]+\.[\w.]+`),
+//   })
+type RegexpRedactor struct {
+	Pattern *regexp.Regexp
+	// Replacement supports the same syntax as regexp.Regexp.ReplaceAllString (default [RedactedPlaceholder]).
+	Replacement string
+}
+
+var _ Redactor = (*RegexpRedactor)(nil)
+
+func (rr *RegexpRedactor) Redact(r *Redaction) {
+	replacement := rr.Replacement
+	if replacement == "" {
+		replacement = RedactedPlaceholder
+	}
+	r.Msg = rr.Pattern.ReplaceAllString(r.Msg, replacement)
+	r.Template = rr.Pattern.ReplaceAllString(r.Template, replacement)
+	for i, attr := range r.Attrs {
+		r.Attrs[i].Value = rr.redactValue(attr.Value, replacement)
+	}
+	for i, arg := range r.Args {
+		r.Args[i] = rr.redactValue(arg, replacement)
+	}
+	r.Details = rr.redactValue(r.Details, replacement)
+}
+
+// Values that are not strings are kept as is when their text doesn't match, so the JSON formatter still marshals them.
+func (rr *RegexpRedactor) redactValue(value any, replacement string) any {
+	text, ok := value.(string)
+	if !ok {
+		if value == nil {
+			return nil
+		}
+		text = fmt.Sprint(value)
+		if !rr.Pattern.MatchString(text) {
+			return value
+		}
+	}
+	return rr.Pattern.ReplaceAllString(text, replacement)
+}
+
+// Replaces the values of the attributes whose key is in the deny-list by [RedactedPlaceholder].
+// Keys are compared case-insensitively.
+type AttrKeyRedactor struct {
+	Keys []string
+}
+
+var _ Redactor = (*AttrKeyRedactor)(nil)
+
+func (ar *AttrKeyRedactor) Redact(r *Redaction) {
+	for i, attr := range r.Attrs {
+		for _, key := range ar.Keys {
+			if strings.EqualFold(attr.Key, key) {
+				r.Attrs[i].Value = RedactedPlaceholder
+				break
+			}
+		}
+	}
+}
+
+// Trims the build machine specific prefixes from the files of the frames.
+// Files in a module cache are trimmed up to the module path (e.g. "github.com/lib/pq@v1.10.0/conn.go"),
+// files of the standard library up to their package (e.g. "net/http/server.go"),
+// and files starting with one of the Prefixes are trimmed of the prefix.
+type TrimPathRedactor struct {
+	Prefixes []string
+}
+
+var _ Redactor = (*TrimPathRedactor)(nil)
+
+// Creates a TrimPathRedactor trimming the module cache, the standard library and the provided build roots,
+// e.g. the directory of the main module on the build machine.
+func NewTrimPathRedactor(buildRoots ...string) *TrimPathRedactor {
+	var prefixes []string
+	for _, root := range buildRoots {
+		if root != "" {
+			prefixes = append(prefixes, strings.TrimSuffix(root, "/")+"/")
+		}
+	}
+	return &TrimPathRedactor{Prefixes: prefixes}
+}
+
+const moduleCacheMarker = "/pkg/mod/"
+
+func (tr *TrimPathRedactor) Redact(r *Redaction) {
+	for i, frame := range r.Frames {
+		if idx := strings.Index(frame.File, moduleCacheMarker); idx >= 0 {
+			r.Frames[i].File = frame.File[idx+len(moduleCacheMarker):]
+			continue
+		}
+		if file := stdFile(frame); file != "" {
+			r.Frames[i].File = file
+			continue
+		}
+		for _, prefix := range tr.Prefixes {
+			if strings.HasPrefix(frame.File, prefix) {
+				r.Frames[i].File = frame.File[len(prefix):]
+				break
+			}
+		}
+	}
+}
+
+// Returns the path of the file of a frame of the standard library relative to GOROOT/src, or an empty string for other frames.
+// The frames are recognized by their package, as the GOROOT of the build machine is unknown at runtime.
+func stdFile(frame StackFrames) string {
+	if !frame.IsStd() {
+		return ""
+	}
+	if frame.RelFile != "" {
+		return frame.RelFile
+	}
+	pkg, _ := SplitFunctionName(frame.Function)
+	// The files of the standard library are in GOROOT/src/<package>/
+	file := pkg + "/" + path.Base(frame.File)
+	if !strings.HasSuffix(frame.File, "/src/"+file) {
+		return ""
+	}
+	return file
+}
@@ -0,0 +1,176 @@
+package betterr
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func sensitiveChain() error {
+	return &BetterError{
+		Msg: "cannot notify john.doe@example.com",
+		Stack: &StaticStacktrace{Frames: []StackFrames{
+			{Function: "github.com/myapp.Notify", File: "/home/ci/src/myapp/notify.go", Line: 12},
+			{Function: "github.com/lib/pq.(*conn).Exec", File: "/home/ci/go/pkg/mod/github.com/lib/pq@v1.10.0/conn.go", Line: 42},
+		}},
+		Attrs: []Attr{
+			{Key: "Authorization", Value: "Bearer abc"},
+			{Key: "recipient", Value: "john.doe@example.com"},
+			SecretAttr("password", "hunter2"),
+		},
+		Wrapped: errors.New("smtp: rejected john.doe@example.com"),
+	}
+}
+
+func withRedactors(t *testing.T, redactors ...Redactor) {
+	previous := Redactors
+	Redactors = redactors
+	t.Cleanup(func() {
+		Redactors = previous
+	})
+}
+
+func TestRedact_AllFormatters(t *testing.T) {
+	withRedactors(t,
+		&RegexpRedactor{Pattern: regexp.MustCompile(`[\w.+-]+@[\w-]+\.[\w.]+`)},
+		&AttrKeyRedactor{Keys: []string{"authorization"}},
+		NewTrimPathRedactor("/home/ci/src/myapp"),
+	)
+	formatters := append([]ErrorFormatter{&GCPErrorReportingFormatter{}, &SentryFormatter{}, MustTemplateFormatter(`{{.Message}}{{range .Attrs}}{{.Value}}{{end}}`)}, allFormatters...)
+	for _, f := range formatters {
+		output := f.Format(sensitiveChain())
+		for _, sensitive := range []string{"john.doe", "Bearer", "hunter2", "/home/ci"} {
+			if strings.Contains(output, sensitive) {
+				t.Errorf("\n%T leaks %q:\n%s", f, sensitive, output)
+			}
+		}
+	}
+	assertEqual(t,
+		"cannot notify [REDACTED]\n"+
+			"    at github.com/myapp.Notify (notify.go:12)\n"+
+			"    at github.com/lib/pq.(*conn).Exec (github.com/lib/pq@v1.10.0/conn.go:42)\n"+
+			"Caused by: smtp: rejected [REDACTED]",
+		new(JavaStyleFormatter).Format(sensitiveChain()))
+}
+
+func TestRedact_JoinedErrors(t *testing.T) {
+	withRedactors(t,
+		&RegexpRedactor{Pattern: regexp.MustCompile(`[\w.+-]+@[\w-]+\.[\w.]+`)},
+		NewTrimPathRedactor("/home/ci/src/myapp"),
+	)
+	first := &BetterError{
+		Msg:   "cannot notify john.doe@example.com",
+		Stack: &StaticStacktrace{Frames: []StackFrames{{Function: "github.com/myapp.Notify", File: "/home/ci/src/myapp/notify.go", Line: 12}}},
+	}
+	second := &BetterError{
+		Msg:   "cannot notify jane.doe@example.com",
+		Stack: &StaticStacktrace{Frames: []StackFrames{{Function: "github.com/myapp.Notify", File: "/home/ci/src/myapp/notify.go", Line: 12}}},
+	}
+	err := Errorf("cannot notify the users: %w, %w", first, second)
+	formatters := append([]ErrorFormatter{&GCPErrorReportingFormatter{}, &SentryFormatter{}, MustTemplateFormatter(GoStyleTemplate)}, allFormatters...)
+	for _, f := range formatters {
+		output := f.Format(err)
+		for _, sensitive := range []string{"john.doe", "jane.doe", "/home/ci"} {
+			if strings.Contains(output, sensitive) {
+				t.Errorf("\n%T leaks %q:\n%s", f, sensitive, output)
+			}
+		}
+	}
+	assertEqual(t, "[cannot notify [REDACTED]; cannot notify [REDACTED]]", Redact(errors.Join(first, second)).Msg)
+	assertEqual(t, "cannot notify the users: [cannot notify [REDACTED]; cannot notify [REDACTED]]", new(GoStyleFormatter).Format(err))
+	assertRegexp(t,
+		"^cannot notify the users\n"+
+			"    at github.com/jjunac/betterr.TestRedact_JoinedErrors \\(.*redact_test.go:\\d+\\)\n(?:    at .*\n)*"+
+			"Caused by: cannot notify \\[REDACTED\\]\n"+
+			"    at github.com/myapp.Notify \\(notify.go:12\\)\n"+
+			"Caused by: cannot notify \\[REDACTED\\]\n"+
+			"    at github.com/myapp.Notify \\(notify.go:12\\)\n$",
+		new(JavaStyleFormatter).Format(err))
+}
+
+func TestRedact_DoesNotModifyTheError(t *testing.T) {
+	withRedactors(t, NewTrimPathRedactor("/home/ci/src/myapp"), &AttrKeyRedactor{Keys: []string{"recipient"}})
+	err := sensitiveChain().(*BetterError)
+	r := Redact(err)
+	assertEqual(t, "notify.go", r.Frames[0].File)
+	assertEqual(t, RedactedPlaceholder, r.Attrs[1].Value.(string))
+	assertEqual(t, "/home/ci/src/myapp/notify.go", err.Stack.GetFrames()[0].File)
+	assertEqual(t, "john.doe@example.com", err.Attrs[1].Value.(string))
+}
+
+func TestSecretAttr(t *testing.T) {
+	err := WithAttrs(New("login failed"), SecretAttr("password", "hunter2"))
+	assertEqual(t, RedactedPlaceholder, Redact(err).Attrs[0].Value.(string))
+	assertEqual(t, RedactedPlaceholder, fmt.Sprint(err.(*BetterError).Attrs[0].Value))
+	assertFalse(t, strings.Contains(new(JsonFormatter).Format(err), "hunter2"))
+	assertFalse(t, strings.Contains(fmt.Sprintf("%v", err.(*BetterError).Attrs), "hunter2"))
+}
+
+func TestTrimPathRedactor(t *testing.T) {
+	r := Redaction{Frames: []StackFrames{
+		{File: "/root/go/pkg/mod/golang.org/x/sync@v0.1.0/errgroup/errgroup.go"},
+		{File: "/build/myapp/cmd/main.go"},
+		{File: "/elsewhere/file.go"},
+		{Function: "net/http.(*conn).serve", File: "/opt/go/src/net/http/server.go"},
+		{Function: "net/http.(*conn).serve", File: "/opt/go/src/net/http/server.go", Module: "std", RelFile: "net/http/server.go"},
+		{Function: "github.com/myapp.main", File: "/elsewhere/src/github.com/myapp/main.go"},
+	}}
+	(&TrimPathRedactor{Prefixes: []string{"/build/myapp/"}}).Redact(&r)
+	assertEqual(t, "golang.org/x/sync@v0.1.0/errgroup/errgroup.go", r.Frames[0].File)
+	assertEqual(t, "cmd/main.go", r.Frames[1].File)
+	assertEqual(t, "/elsewhere/file.go", r.Frames[2].File)
+	assertEqual(t, "net/http/server.go", r.Frames[3].File)
+	assertEqual(t, "net/http/server.go", r.Frames[4].File)
+	assertEqual(t, "/elsewhere/src/github.com/myapp/main.go", r.Frames[5].File)
+
+	// The frames captured at runtime
+	r = Redact(New("failed"))
+	NewTrimPathRedactor().Redact(&r)
+	assertEqual(t, "testing/testing.go", r.Frames[1].File)
+}
+
+func TestSecret(t *testing.T) {
+	err := Errorf("cannot login as %s with password %s (%d)", "bob", Secret("hunter2"), Secret(1234))
+	assertEqual(t, "cannot login as bob with password [REDACTED] ([REDACTED])", new(GoStyleFormatter).Format(err))
+	assertEqual(t, RedactedPlaceholder, Redact(err).Args[1].(string))
+	json := new(JsonFormatter).Format(err)
+	assertFalse(t, strings.Contains(json, "hunter2"))
+	assertFalse(t, strings.Contains(json, "1234"))
+}
+
+func TestRegexpRedactor_Args(t *testing.T) {
+	withRedactors(t, &RegexpRedactor{Pattern: regexp.MustCompile(`[\w.+-]+@[\w-]+\.[\w.]+`)})
+	r := Redact(Errorf("cannot notify %s", "john.doe@example.com"))
+	assertEqual(t, "cannot notify [REDACTED]", r.Msg)
+	assertEqual(t, RedactedPlaceholder, r.Args[0].(string))
+}
+
+type notifiedUser struct {
+	Name  string
+	Email string
+}
+
+func TestRegexpRedactor_StructArgs(t *testing.T) {
+	withRedactors(t, &RegexpRedactor{Pattern: regexp.MustCompile(`[\w.+-]+@[\w-]+\.[\w.]+`)})
+	err := Errorf("cannot notify %v and %v (attempt %d)",
+		notifiedUser{Name: "John", Email: "john.doe@example.com"}, []string{"jane.doe@example.com"}, 3)
+	r := Redact(err)
+	assertEqual(t, "{John [REDACTED]}", r.Args[0].(string))
+	assertEqual(t, "[[REDACTED]]", r.Args[1].(string))
+	// Values that don't match are kept as is
+	assertEqual(t, 3, r.Args[2].(int))
+	json := new(JsonFormatter).Format(err)
+	assertFalse(t, strings.Contains(json, "doe@example.com"))
+	assertTrue(t, strings.Contains(json, `"args":["{John [REDACTED]}","[[REDACTED]]",3]`))
+}
+
+func TestRegexpRedactor_Template(t *testing.T) {
+	withRedactors(t, &RegexpRedactor{Pattern: regexp.MustCompile(`[\w.+-]+@[\w-]+\.[\w.]+`)})
+	err := Errorf("cannot notify john.doe@example.com: %s", "mailbox full")
+	assertEqual(t, "cannot notify [REDACTED]: %s", Redact(err).Template)
+	for _, f := range []ErrorFormatter{&JsonFormatter{}, MustTemplateFormatter(`{{.Template}}`)} {
+		assertFalse(t, strings.Contains(f.Format(err), "john.doe"))
+	}
+}
@@ -0,0 +1,338 @@
+// Package sentry sends BetterErrors to Sentry, without depending on the Sentry SDK.
+//
+// Errors are converted with [betterr.SentryFormatter], queued, and posted as envelopes by a background worker.
+// The queue is bounded: when it is full, or when Sentry asked to slow down, new errors are dropped
+// instead of blocking the application.
+package sentry
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jjunac/betterr"
+)
+
+const clientName = "betterr-sentry/1.0"
+
+// Options to configure a [Client].
+// Only DSN is mandatory, the zero value of the other fields selects a sensible default.
+type Options struct {
+	DSN string
+	// Environment, Release and ServerName are added to every event.
+	Environment string
+	Release     string
+	ServerName  string
+	// InAppPrefixes is passed to the [betterr.SentryFormatter].
+	InAppPrefixes []string
+	// QueueSize is the number of events waiting to be sent before new ones are dropped (default 100).
+	QueueSize int
+	// BatchSize is the number of events the worker accumulates before sending them (default 10).
+	// Sentry accepts a single event per envelope, so a batch is sent as consecutive requests.
+	BatchSize int
+	// FlushInterval is the maximum time an event waits in an incomplete batch (default 1s).
+	FlushInterval time.Duration
+	// MaxRetries is the number of times a request failing with a network or server error is retried (default 3).
+	// As zero selects the default, a negative value, e.g. -1, disables the retries.
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry, doubled at each attempt (default 1s).
+	RetryBackoff time.Duration
+	// HTTPClient sends the requests (default http.DefaultClient).
+	HTTPClient *http.Client
+}
+
+// Client captures errors and sends them to Sentry in the background.
+// A Client is safe for concurrent use. Call [Client.Close] to send the pending events before exiting.
+type Client struct {
+	options   Options
+	dsn       *DSN
+	formatter *betterr.SentryFormatter
+	queue     chan *betterr.SentryEvent
+	flushes   chan chan struct{}
+	done      chan struct{}
+	stopped   chan struct{}
+
+	// Held for reading while an event is queued, so Close cannot stop the worker before it is drained
+	closeMu sync.RWMutex
+	closed  bool
+
+	mu           sync.Mutex
+	limitedUntil time.Time
+	// Overridable for tests
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// Creates a new Client and starts its background worker.
+func NewClient(options Options) (*Client, error) {
+	dsn, err := ParseDSN(options.DSN)
+	if err != nil {
+		return nil, err
+	}
+	if options.QueueSize <= 0 {
+		options.QueueSize = 100
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = 10
+	}
+	if options.FlushInterval <= 0 {
+		options.FlushInterval = time.Second
+	}
+	if options.MaxRetries == 0 {
+		options.MaxRetries = 3
+	} else if options.MaxRetries < 0 {
+		options.MaxRetries = 0
+	}
+	if options.RetryBackoff <= 0 {
+		options.RetryBackoff = time.Second
+	}
+	if options.HTTPClient == nil {
+		options.HTTPClient = http.DefaultClient
+	}
+	c := &Client{
+		options:   options,
+		dsn:       dsn,
+		formatter: &betterr.SentryFormatter{InAppPrefixes: options.InAppPrefixes},
+		queue:     make(chan *betterr.SentryEvent, options.QueueSize),
+		flushes:   make(chan chan struct{}),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+		now:       time.Now,
+		sleep:     time.Sleep,
+	}
+	go c.run()
+	return c, nil
+}
+
+// Queues the error to be sent to Sentry, with its chain, attributes and stacks.
+// Returns the ID of the event, or an empty string if the error is nil or the event was dropped
+// because the queue is full, Sentry is rate limiting the client, or the client is closed.
+func (c *Client) Capture(err error) string {
+	if err == nil || c.isRateLimited() {
+		return ""
+	}
+	event := c.formatter.Event(err)
+	event.EventID = newEventID()
+	event.Timestamp = c.now().UTC().Format(time.RFC3339Nano)
+	event.Environment = c.options.Environment
+	event.Release = c.options.Release
+	event.ServerName = c.options.ServerName
+
+	c.closeMu.RLock()
+	defer c.closeMu.RUnlock()
+	if c.closed {
+		return ""
+	}
+	select {
+	case c.queue <- event:
+		return event.EventID
+	default:
+		return ""
+	}
+}
+
+// Sends the queued events and waits until they are sent or the timeout expires.
+// Returns false if the timeout expired first.
+func (c *Client) Flush(timeout time.Duration) bool {
+	flushed := make(chan struct{})
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case c.flushes <- flushed:
+	case <-c.stopped:
+		return true
+	case <-timer.C:
+		return false
+	}
+	select {
+	case <-flushed:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// Sends the queued events and stops the background worker.
+// Errors captured after Close are dropped.
+func (c *Client) Close() {
+	c.closeMu.Lock()
+	if !c.closed {
+		c.closed = true
+		close(c.done)
+	}
+	c.closeMu.Unlock()
+	<-c.stopped
+}
+
+func (c *Client) run() {
+	defer close(c.stopped)
+	ticker := time.NewTicker(c.options.FlushInterval)
+	defer ticker.Stop()
+	batch := make([]*betterr.SentryEvent, 0, c.options.BatchSize)
+	for {
+		select {
+		case event := <-c.queue:
+			batch = append(batch, event)
+			if len(batch) >= c.options.BatchSize {
+				batch = c.sendBatch(batch)
+			}
+		case <-ticker.C:
+			batch = c.sendBatch(batch)
+		case flushed := <-c.flushes:
+			batch = c.sendBatch(c.drain(batch))
+			close(flushed)
+		case <-c.done:
+			c.sendBatch(c.drain(batch))
+			return
+		}
+	}
+}
+
+func (c *Client) drain(batch []*betterr.SentryEvent) []*betterr.SentryEvent {
+	for {
+		select {
+		case event := <-c.queue:
+			batch = append(batch, event)
+		default:
+			return batch
+		}
+	}
+}
+
+// Sends the events of the batch and returns the emptied batch, to reuse its buffer.
+func (c *Client) sendBatch(batch []*betterr.SentryEvent) []*betterr.SentryEvent {
+	for _, event := range batch {
+		c.send(event)
+	}
+	return batch[:0]
+}
+
+// Posts the event as an envelope, retrying on network and server errors.
+// Events that cannot be sent are dropped, there is nobody to report the failure to.
+func (c *Client) send(event *betterr.SentryEvent) {
+	envelope, err := c.envelope(event)
+	if err != nil {
+		return
+	}
+	backoff := c.options.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		if c.isRateLimited() {
+			return
+		}
+		retry := c.post(envelope)
+		if !retry || attempt >= c.options.MaxRetries {
+			return
+		}
+		c.sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// Posts the envelope and reports whether the request should be retried.
+func (c *Client) post(envelope []byte) (retry bool) {
+	req, err := http.NewRequest(http.MethodPost, c.dsn.EnvelopeURL(), bytes.NewReader(envelope))
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", c.dsn.AuthHeader())
+	resp, err := c.options.HTTPClient.Do(req)
+	if err != nil {
+		return true
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	c.updateRateLimits(resp)
+	return resp.StatusCode >= 500
+}
+
+// Builds an envelope holding a single event item.
+// See https://develop.sentry.dev/sdk/envelopes/
+func (c *Client) envelope(event *betterr.SentryEvent) ([]byte, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, betterr.Decorate(err, "cannot marshal Sentry event")
+	}
+	header, err := json.Marshal(map[string]string{
+		"event_id": event.EventID,
+		"sent_at":  c.now().UTC().Format(time.RFC3339Nano),
+		"dsn":      c.dsn.String(),
+	})
+	if err != nil {
+		return nil, betterr.Decorate(err, "cannot marshal Sentry envelope header")
+	}
+	buf := bytes.Buffer{}
+	buf.Write(header)
+	buf.WriteString("\n{\"type\":\"event\",\"length\":")
+	buf.WriteString(strconv.Itoa(len(payload)))
+	buf.WriteString("}\n")
+	buf.Write(payload)
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func (c *Client) isRateLimited() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now().Before(c.limitedUntil)
+}
+
+// Reads the rate limits sent by Sentry, from the X-Sentry-Rate-Limits header or, for 429 responses, the Retry-After header.
+// See https://develop.sentry.dev/sdk/rate-limiting/
+func (c *Client) updateRateLimits(resp *http.Response) {
+	var delay time.Duration
+	if header := resp.Header.Get("X-Sentry-Rate-Limits"); header != "" {
+		delay = parseRateLimits(header)
+	} else if resp.StatusCode == http.StatusTooManyRequests {
+		delay = time.Minute
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			delay = time.Duration(seconds) * time.Second
+		}
+	}
+	if delay <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if until := c.now().Add(delay); until.After(c.limitedUntil) {
+		c.limitedUntil = until
+	}
+}
+
+// Returns the longest delay of the limits applying to error events.
+// The header is a list of "retry_after:categories:scope", categories being empty for all categories.
+func parseRateLimits(header string) time.Duration {
+	var delay time.Duration
+	for _, limit := range strings.Split(header, ",") {
+		parts := strings.Split(strings.TrimSpace(limit), ":")
+		if len(parts) < 2 {
+			continue
+		}
+		seconds, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+		applies := parts[1] == ""
+		for _, category := range strings.Split(parts[1], ";") {
+			applies = applies || category == "error"
+		}
+		if d := time.Duration(seconds) * time.Second; applies && d > delay {
+			delay = d
+		}
+	}
+	return delay
+}
+
+func newEventID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
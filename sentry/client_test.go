@@ -0,0 +1,286 @@
+package sentry
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jjunac/betterr"
+)
+
+// Stand-in for Sentry, recording the envelopes it receives.
+// The handler decides the response of each request, and is called with the number of the request (starting at 1).
+type fakeSentry struct {
+	*httptest.Server
+	mu        sync.Mutex
+	requests  int
+	events    []betterr.SentryEvent
+	authParts []string
+	respond   func(w http.ResponseWriter, request int)
+}
+
+func newFakeSentry(t *testing.T, respond func(w http.ResponseWriter, request int)) *fakeSentry {
+	f := &fakeSentry{respond: respond}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.requests++
+		request := f.requests
+		f.authParts = append(f.authParts, r.Header.Get("X-Sentry-Auth"))
+		f.mu.Unlock()
+
+		if r.URL.Path != "/api/42/envelope/" {
+			t.Errorf("\nUnexpected path: %s", r.URL.Path)
+		}
+		scanner := bufio.NewScanner(r.Body)
+		scanner.Buffer(nil, 1<<20)
+		var lines []string
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		if len(lines) != 3 || !strings.Contains(lines[1], `"type":"event"`) {
+			t.Errorf("\nMalformed envelope: %q", lines)
+			return
+		}
+		var event betterr.SentryEvent
+		if err := json.Unmarshal([]byte(lines[2]), &event); err != nil {
+			t.Errorf("\nInvalid event: %v", err)
+		}
+		if respond != nil {
+			respond(w, request)
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.events = append(f.events, event)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeSentry) dsn() string {
+	return strings.Replace(f.URL, "http://", "http://public@", 1) + "/42"
+}
+
+func (f *fakeSentry) receivedEvents() []betterr.SentryEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]betterr.SentryEvent(nil), f.events...)
+}
+
+func newTestClient(t *testing.T, options Options) *Client {
+	t.Helper()
+	client, err := NewClient(options)
+	if err != nil {
+		t.Fatalf("\nCannot create client: %v", err)
+	}
+	client.sleep = func(time.Duration) {}
+	t.Cleanup(client.Close)
+	return client
+}
+
+func TestClient_Capture(t *testing.T) {
+	server := newFakeSentry(t, nil)
+	client := newTestClient(t, Options{DSN: server.dsn(), Environment: "test", Release: "1.0.0"})
+
+	err := betterr.Decorate(
+		betterr.WithAttrs(betterr.New("connection refused"), betterr.Attr{Key: "host", Value: "db"}),
+		"cannot load user")
+	id := client.Capture(err)
+	if len(id) != 32 {
+		t.Errorf("\nExpected a 32 characters event ID, got %q", id)
+	}
+	if !client.Flush(time.Second) {
+		t.Fatalf("\nFlush timed out")
+	}
+
+	events := server.receivedEvents()
+	if len(events) != 1 {
+		t.Fatalf("\nExpected 1 event, got %d", len(events))
+	}
+	event := events[0]
+	if event.EventID != id || event.Environment != "test" || event.Release != "1.0.0" || event.Timestamp == "" {
+		t.Errorf("\nUnexpected event header: %+v", event)
+	}
+	values := event.Exception.Values
+	if len(values) != 2 || values[0].Value != "connection refused" || values[1].Value != "cannot load user" {
+		t.Errorf("\nUnexpected exception values: %+v", values)
+	}
+	if values[0].Stacktrace == nil || len(values[0].Stacktrace.Frames) == 0 {
+		t.Errorf("\nExpected a stacktrace")
+	}
+	if event.Extra["host"] != "db" {
+		t.Errorf("\nExpected the attributes in extra, got %v", event.Extra)
+	}
+	if !strings.Contains(server.authParts[0], "sentry_key=public") {
+		t.Errorf("\nUnexpected auth header: %s", server.authParts[0])
+	}
+}
+
+func TestClient_Batching(t *testing.T) {
+	server := newFakeSentry(t, nil)
+	client := newTestClient(t, Options{DSN: server.dsn(), BatchSize: 5, FlushInterval: time.Hour})
+
+	for i := 0; i < 4; i++ {
+		client.Capture(betterr.New("batched"))
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := len(server.receivedEvents()); n != 0 {
+		t.Errorf("\nExpected the incomplete batch to wait, got %d events", n)
+	}
+	client.Capture(betterr.New("batched"))
+	client.Flush(time.Second)
+	if n := len(server.receivedEvents()); n != 5 {
+		t.Errorf("\nExpected 5 events, got %d", n)
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	server := newFakeSentry(t, func(w http.ResponseWriter, request int) {
+		if request <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	client := newTestClient(t, Options{DSN: server.dsn()})
+
+	client.Capture(errors.New("flaky"))
+	client.Flush(time.Second)
+	if n := len(server.receivedEvents()); n != 3 {
+		t.Errorf("\nExpected 3 attempts, got %d", n)
+	}
+}
+
+func TestClient_RetryGivesUp(t *testing.T) {
+	server := newFakeSentry(t, func(w http.ResponseWriter, request int) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	client := newTestClient(t, Options{DSN: server.dsn(), MaxRetries: 2})
+
+	client.Capture(errors.New("always failing"))
+	client.Flush(time.Second)
+	if n := len(server.receivedEvents()); n != 3 {
+		t.Errorf("\nExpected 1 attempt and 2 retries, got %d", n)
+	}
+}
+
+func TestClient_NoRetry(t *testing.T) {
+	server := newFakeSentry(t, func(w http.ResponseWriter, request int) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	client := newTestClient(t, Options{DSN: server.dsn(), MaxRetries: -1})
+
+	client.Capture(errors.New("always failing"))
+	client.Flush(time.Second)
+	if n := len(server.receivedEvents()); n != 1 {
+		t.Errorf("\nExpected a single attempt, got %d", n)
+	}
+}
+
+func TestClient_RateLimit(t *testing.T) {
+	server := newFakeSentry(t, func(w http.ResponseWriter, request int) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	client := newTestClient(t, Options{DSN: server.dsn()})
+
+	if client.Capture(errors.New("first")) == "" {
+		t.Errorf("\nExpected the first error to be queued")
+	}
+	client.Flush(time.Second)
+	if client.Capture(errors.New("second")) != "" {
+		t.Errorf("\nExpected the second error to be dropped")
+	}
+	client.Flush(time.Second)
+	if n := len(server.receivedEvents()); n != 1 {
+		t.Errorf("\nExpected a single request, got %d", n)
+	}
+}
+
+func TestClient_BoundedQueue(t *testing.T) {
+	unblock := make(chan struct{})
+	server := newFakeSentry(t, func(w http.ResponseWriter, request int) {
+		<-unblock
+	})
+	client := newTestClient(t, Options{DSN: server.dsn(), QueueSize: 2, BatchSize: 1})
+
+	dropped := 0
+	for i := 0; i < 10; i++ {
+		if client.Capture(errors.New("burst")) == "" {
+			dropped++
+		}
+	}
+	close(unblock)
+	// 1 event being sent, 2 in the queue, and maybe 1 in the batch of the worker
+	if dropped < 6 {
+		t.Errorf("\nExpected at least 6 dropped events, got %d", dropped)
+	}
+}
+
+func TestClient_CaptureAfterClose(t *testing.T) {
+	server := newFakeSentry(t, nil)
+	client := newTestClient(t, Options{DSN: server.dsn()})
+	client.Capture(errors.New("before"))
+	client.Close()
+	if client.Capture(errors.New("after")) != "" {
+		t.Errorf("\nExpected the error to be dropped")
+	}
+	if n := len(server.receivedEvents()); n != 1 {
+		t.Errorf("\nExpected Close to send the pending event, got %d events", n)
+	}
+}
+
+// Run with -race: the events accepted while the client is closing must still be sent
+func TestClient_CaptureDuringClose(t *testing.T) {
+	server := newFakeSentry(t, nil)
+	client := newTestClient(t, Options{DSN: server.dsn(), QueueSize: 10000, BatchSize: 10000})
+
+	var mu sync.Mutex
+	accepted := map[string]bool{}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Until the client is closed, or its queue full
+			for {
+				id := client.Capture(errors.New("concurrent"))
+				if id == "" {
+					return
+				}
+				mu.Lock()
+				accepted[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	client.Close()
+	wg.Wait()
+
+	for _, event := range server.receivedEvents() {
+		delete(accepted, event.EventID)
+	}
+	if len(accepted) > 0 {
+		t.Errorf("\nExpected the accepted events to be sent, %d were lost", len(accepted))
+	}
+}
+
+func TestParseRateLimits(t *testing.T) {
+	testCases := []struct {
+		header   string
+		expected time.Duration
+	}{
+		{"60::organization", time.Minute},
+		{"60:transaction:key", 0},
+		{"60:transaction:key, 120:error;default:key", 2 * time.Minute},
+		{"garbage", 0},
+	}
+	for _, tc := range testCases {
+		if actual := parseRateLimits(tc.header); actual != tc.expected {
+			t.Errorf("\n%q: expected %v, got %v", tc.header, tc.expected, actual)
+		}
+	}
+}
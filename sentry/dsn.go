@@ -0,0 +1,62 @@
+package sentry
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jjunac/betterr"
+)
+
+// DSN is a parsed Sentry Data Source Name, e.g. "https://<public_key>@o0.ingest.sentry.io/<project_id>".
+type DSN struct {
+	Scheme    string
+	PublicKey string
+	Host      string
+	Path      string
+	ProjectID string
+	raw       string
+}
+
+// Parses a Sentry DSN.
+func ParseDSN(rawDSN string) (*DSN, error) {
+	u, err := url.Parse(rawDSN)
+	if err != nil {
+		return nil, betterr.Decorate(err, "invalid Sentry DSN")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, betterr.Errorf("invalid Sentry DSN: unsupported scheme %q", u.Scheme)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, betterr.New("invalid Sentry DSN: missing public key")
+	}
+	path, projectID := "", strings.TrimSuffix(u.Path, "/")
+	if i := strings.LastIndexByte(projectID, '/'); i >= 0 {
+		path, projectID = projectID[:i], projectID[i+1:]
+	}
+	if projectID == "" {
+		return nil, betterr.New("invalid Sentry DSN: missing project ID")
+	}
+	return &DSN{
+		Scheme:    u.Scheme,
+		PublicKey: u.User.Username(),
+		Host:      u.Host,
+		Path:      path,
+		ProjectID: projectID,
+		raw:       rawDSN,
+	}, nil
+}
+
+// Returns the URL of the envelope endpoint of the project.
+func (d *DSN) EnvelopeURL() string {
+	return fmt.Sprintf("%s://%s%s/api/%s/envelope/", d.Scheme, d.Host, d.Path, d.ProjectID)
+}
+
+// Returns the value of the X-Sentry-Auth header authenticating the requests.
+func (d *DSN) AuthHeader() string {
+	return fmt.Sprintf("Sentry sentry_version=7, sentry_key=%s, sentry_client=%s", d.PublicKey, clientName)
+}
+
+func (d *DSN) String() string {
+	return d.raw
+}
@@ -0,0 +1,36 @@
+package sentry
+
+import (
+	"flag"
+	"testing"
+)
+
+// There are no golden files in this package, the flag is accepted so that go test ./... -update runs
+var _ = flag.Bool("update", false, "no effect, this package has no golden files")
+
+func TestParseDSN(t *testing.T) {
+	dsn, err := ParseDSN("https://abc123@o1.ingest.sentry.io/prefix/42")
+	if err != nil {
+		t.Fatalf("\nUnexpected error: %v", err)
+	}
+	if dsn.PublicKey != "abc123" || dsn.ProjectID != "42" {
+		t.Errorf("\nUnexpected DSN: %+v", dsn)
+	}
+	if url := dsn.EnvelopeURL(); url != "https://o1.ingest.sentry.io/prefix/api/42/envelope/" {
+		t.Errorf("\nUnexpected envelope URL: %s", url)
+	}
+}
+
+func TestParseDSN_Invalid(t *testing.T) {
+	for _, raw := range []string{
+		"",
+		"ftp://abc123@sentry.io/42",
+		"https://sentry.io/42",
+		"https://abc123@sentry.io/",
+		"://",
+	} {
+		if _, err := ParseDSN(raw); err == nil {
+			t.Errorf("\nExpected an error for %q", raw)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package betterr
 
-import "runtime"
+import (
+	"reflect"
+	"runtime"
+	"strings"
+)
 
 type Stacktrace interface {
 	GetFrames() []StackFrames
@@ -11,6 +15,30 @@ type StackFrames struct {
 	Function string `json:"function"`
 	File     string `json:"file"`
 	Line     int    `json:"line"`
+	// Package is the import path of the package of the function, e.g. "github.com/lib/pq".
+	Package string `json:"package,omitempty"`
+	// Module and ModuleVersion identify the module providing the package, e.g. "github.com/lib/pq" and "v1.10.0".
+	// The module of the standard library is "std".
+	Module        string `json:"module,omitempty"`
+	ModuleVersion string `json:"module_version,omitempty"`
+	// RelFile is the path of the file relative to the root of its module, e.g. "conn.go" or "net/http/server.go".
+	RelFile string `json:"rel_file,omitempty"`
+
+	// The following fields are parsed from Function or only meaningful in the process that captured the stack,
+	// so they are not serialized.
+
+	// Receiver is the receiver type of methods, without type parameters, e.g. "*Client" or "Date".
+	Receiver string `json:"-"`
+	// Name is the name of the function or method, without its package, receiver and closure suffix, e.g. "Run".
+	// For closures, it is the name of the enclosing function.
+	Name string `json:"-"`
+	// Closure is true for anonymous functions, e.g. "github.com/myapp.(*Worker).Run.func1".
+	Closure bool `json:"-"`
+	// Inlined is true when the function was inlined into its caller by the compiler.
+	Inlined bool `json:"-"`
+	// PC is the program counter of the frame, and EntryOffset its offset from the entry of the function.
+	PC          uintptr `json:"-"`
+	EntryOffset uintptr `json:"-"`
 }
 
 var _ Stacktrace = (*RuntimeStacktrace)(nil)
@@ -19,26 +47,70 @@ type RuntimeStacktrace struct {
 	Stack []uintptr
 }
 
+// Maximum number of frames of the stack traces captured at runtime.
+const maxStackDepth = 32
+
+// RuntimeStacktrace allocated along with the array of its program counters.
+type runtimeStacktraceWithPCs struct {
+	RuntimeStacktrace
+	pcs [maxStackDepth]uintptr
+}
+
 func NewRuntimeStacktrace(skip int) Stacktrace {
-	var pcs [32]uintptr
-	n := runtime.Callers(skip+2, pcs[:])
-	return &RuntimeStacktrace{
-		Stack: pcs[:n],
+	s := &runtimeStacktraceWithPCs{}
+	n := runtime.Callers(skip+2, s.pcs[:])
+	s.Stack = s.pcs[:n:n]
+	return &s.RuntimeStacktrace
+}
+
+// BetterError allocated along with its stack trace and the array of its program counters.
+type betterErrorWithStack struct {
+	BetterError
+	stack runtimeStacktraceWithPCs
+}
+
+// Code pointer of NewRuntimeStacktrace, to know whether GetStacktrace was replaced.
+var runtimeStacktraceCode = reflect.ValueOf(NewRuntimeStacktrace).Pointer()
+
+// Creates the BetterError of the constructors, holding the stack trace starting skip frames above the caller of this function,
+// like GetStacktrace(skip) would.
+// Unless GetStacktrace was replaced, the error and its stack trace take a single allocation.
+func newBetterError(skip int) *BetterError {
+	if reflect.ValueOf(GetStacktrace).Pointer() != runtimeStacktraceCode {
+		return &BetterError{Stack: GetStacktrace(skip + 1)}
 	}
+	e := &betterErrorWithStack{}
+	n := runtime.Callers(skip+2, e.stack.pcs[:])
+	e.stack.Stack = e.stack.pcs[:n:n]
+	e.Stack = &e.stack.RuntimeStacktrace
+	return &e.BetterError
 }
 
 func (s RuntimeStacktrace) GetFrames() []StackFrames {
+	// The stack is empty when more frames were skipped than there are, CallersFrames would give a zero frame
+	if len(s.Stack) == 0 {
+		return nil
+	}
 	frames := runtime.CallersFrames(s.Stack)
 	var frameList []StackFrames
-	var frame runtime.Frame 
+	var frame runtime.Frame
 	more := true
 	for more {
 		frame, more = frames.Next()
-		frameList = append(frameList, StackFrames{
+		stackFrame := StackFrames{
 			File:     frame.File,
 			Function: frame.Function,
 			Line:     frame.Line,
-		})
+			// The runtime only knows the function of the physical frame, inlined ones have none
+			Inlined: frame.Func == nil && frame.Function != "",
+			PC:      frame.PC,
+		}
+		if frame.Entry != 0 && frame.PC >= frame.Entry {
+			stackFrame.EntryOffset = frame.PC - frame.Entry
+		}
+		parseFunctionName(&stackFrame)
+		resolveModule(&stackFrame)
+		frameList = append(frameList, stackFrame)
 	}
 	return frameList
 }
@@ -46,3 +118,92 @@ func (s RuntimeStacktrace) GetFrames() []StackFrames {
 func (s RuntimeStacktrace) FramesLen() int {
 	return len(s.Stack)
 }
+
+// Splits a fully qualified function name, as found in [StackFrames.Function], into its package path and its name.
+// e.g. "github.com/myapp/pkg.(*T).Run" gives "github.com/myapp/pkg" and "(*T).Run".
+func SplitFunctionName(function string) (pkg, name string) {
+	lastSlash := strings.LastIndexByte(function, '/')
+	if i := strings.IndexByte(function[lastSlash+1:], '.'); i >= 0 {
+		dot := lastSlash + 1 + i
+		return function[:dot], function[dot+1:]
+	}
+	return "", function
+}
+
+// Fills the receiver, name and closure flag of the frame from its function.
+// e.g. "github.com/myapp/pkg.(*T[...]).Run.func1.2" gives "*T", "Run" and true.
+func parseFunctionName(frame *StackFrames) {
+	_, name := SplitFunctionName(frame.Function)
+	name = stripTypeParams(name)
+	if strings.HasPrefix(name, "(") {
+		if end := strings.Index(name, ")."); end >= 0 {
+			frame.Receiver = name[1:end]
+			name = name[end+2:]
+		}
+	}
+	// Package level closures of older Go versions are named "glob..func1"
+	parts := strings.FieldsFunc(name, func(r rune) bool { return r == '.' })
+	for i, part := range parts {
+		if i > 0 && isClosureName(part) {
+			frame.Closure = true
+			parts = parts[:i]
+			break
+		}
+	}
+	switch {
+	case len(parts) == 0:
+		// Empty or malformed, e.g. "pkg.", there is no name to extract
+		frame.Name = frame.Function
+	case len(parts) == 2 && parts[0] == "init" && isNumber(parts[1]):
+		// Package initializers of variables are named "init.0", "init.1"...
+		frame.Name = "init"
+	case len(parts) == 2 && frame.Receiver == "":
+		// Methods with value receivers are not parenthesized
+		frame.Receiver, frame.Name = parts[0], parts[1]
+	default:
+		frame.Name = parts[len(parts)-1]
+	}
+}
+
+// Closures are named "func1", "func2"... and goroutines and defers of closures "gowrap1" and "deferwrap1".
+func isClosureName(name string) bool {
+	for _, prefix := range []string{"func", "gowrap", "deferwrap"} {
+		if strings.HasPrefix(name, prefix) && isNumber(name[len(prefix):]) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Removes the type parameters of generic functions and types, which the runtime prints as "[...]".
+func stripTypeParams(name string) string {
+	return strings.ReplaceAll(name, "[...]", "")
+}
+
+var _ Stacktrace = (*StaticStacktrace)(nil)
+
+// StaticStacktrace is a Stacktrace made of already resolved frames.
+// It is used for stacks that were not captured by this process, e.g. received from a remote service.
+type StaticStacktrace struct {
+	Frames []StackFrames
+}
+
+func (s *StaticStacktrace) GetFrames() []StackFrames {
+	return s.Frames
+}
+
+func (s *StaticStacktrace) FramesLen() int {
+	return len(s.Frames)
+}
@@ -45,3 +45,10 @@ func TestRuntimeStacktrace_2Deep(t *testing.T) {
 	assertTrue(t, strings.HasSuffix(stack[1].File, "/stacktrace_test.go"))
 	// The rest is test framework frames
 }
+
+func TestStaticStacktrace(t *testing.T) {
+	frames := []StackFrames{{Function: "github.com/myapp.main", File: "main.go", Line: 45}}
+	err := &BetterError{Msg: "remote error", Stack: &StaticStacktrace{Frames: frames}}
+	assertEqual(t, 1, err.Stack.FramesLen())
+	assertEqual(t, "remote error\n    at github.com/myapp.main (main.go:45)\n", new(JavaStyleFormatter).Format(err))
+}
@@ -0,0 +1,196 @@
+package betterr
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Template reproducing the output of [GoStyleFormatter].
+const GoStyleTemplate = `{{define "go"}}{{if or .IsBetterError (not .Causes)}}{{.Message}}{{end}}{{with .Cause}}: {{template "go" .}}{{end}}` +
+	`{{with .Causes}}{{if $.IsBetterError}}: {{end}}[{{range $i, $cause := .}}{{if $i}}; {{end}}{{template "go" $cause}}{{end}}]{{end}}{{end}}` +
+	`{{template "go" .}}`
+
+// Template reproducing the output of [JavaStyleFormatter].
+const JavaStyleTemplate = `{{define "java"}}{{if .RemoteService}}{{if .Depth}}Caused by {{end}}(remote service {{.RemoteService}}): ` +
+	`{{else if .Depth}}Caused by: {{end}}{{.Message}}{{if or .IsBetterError .Frames}}
+{{range .Frames}}    at {{.Function}} ({{.File}}:{{.Line}})
+{{end}}{{else if not .Last}}
+{{end}}{{with .Cause}}{{template "java" .}}{{end}}{{range .Causes}}{{template "java" .}}{{end}}{{end}}` +
+	`{{template "java" .}}`
+
+// TemplateData is the data model passed to the templates of a [TemplateFormatter].
+// The template is executed with the outermost error, the rest of the chain is reachable through Cause.
+type TemplateData struct {
+	// Message of the error, without the message of its causes.
+	Message string
+	// Template and Args are the format string and arguments of the message, see [BetterError.Template].
+	Template string
+	Args     []any
+	// Frames of the stack trace. For the errors that are not BetterErrors, they are the frames found by the [StackExtractors].
+	Frames []StackFrames
+	// Attributes attached to the error with [WithAttrs].
+	Attrs []Attr
+	// Cause is the wrapped error, nil if there is none.
+	Cause *TemplateData
+	// Causes are the errors of the join wrapped by the error (see errors.Join), Cause being nil.
+	// The outermost error can be a join too, whose Message is the one of [GoStyleFormatter].
+	Causes []*TemplateData
+	// Depth of the error in the chain, 0 for the outermost error.
+	Depth int
+	// RemoteService is the name of the service the error was received from, see [RemoteServiceKey].
+	RemoteService string
+	// IsBetterError is false for plain Go errors, which have neither attributes nor stack, unless the [StackExtractors] find one.
+	IsBetterError bool
+	// Last is true for the last error of the output, i.e. the deepest error of the last cause.
+	Last bool
+}
+
+// TemplateFuncs are the helper functions available in the templates of a [TemplateFormatter]:
+//   - shortFile: the base name of a file path ("/src/myapp/file.go" -> "file.go")
+//   - shortFunc: the function name without its package path ("github.com/myapp.(*T).Run" -> "(*T).Run")
+//   - indent: prefixes every line of a string with the given number of spaces
+var TemplateFuncs = template.FuncMap{
+	"shortFile": shortFile,
+	"shortFunc": shortFunc,
+	"indent":    indent,
+}
+
+// Formats the error using a text/template.
+// The template is executed with a [TemplateData] and can use the [TemplateFuncs].
+// Use [GoStyleTemplate] and [JavaStyleTemplate] as starting points for custom layouts.
+// Example:
+//
+//	f := betterr.MustTemplateFormatter(`{{.Message}}{{range .Frames}} <- {{shortFunc .Function}}{{end}}`)
+//	// Output: failed to process <- MyFunction <- main
+type TemplateFormatter struct {
+	Template *template.Template
+}
+
+var _ StreamFormatter = (*TemplateFormatter)(nil)
+
+// Creates a new TemplateFormatter by parsing the provided template text.
+func NewTemplateFormatter(text string) (*TemplateFormatter, error) {
+	tmpl, err := template.New("betterr").Funcs(TemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, Decorate(err, "failed to parse error template")
+	}
+	return &TemplateFormatter{Template: tmpl}, nil
+}
+
+// Same as [NewTemplateFormatter], but panics if the template cannot be parsed.
+// Intended to initialize formatters in global variables.
+func MustTemplateFormatter(text string) *TemplateFormatter {
+	f, err := NewTemplateFormatter(text)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func (f *TemplateFormatter) Format(err error) string {
+	sb := strings.Builder{}
+	if execErr := f.FormatTo(&sb, err); execErr != nil {
+		// Same convention as fmt for bad verbs, so the failure is visible in the output
+		sb.WriteString("%!(TEMPLATE ")
+		sb.WriteString(execErr.Error())
+		sb.WriteByte(')')
+	}
+	return sb.String()
+}
+
+// Executes the template on the error and writes the result to w.
+// Returns the template execution error, if any.
+func (f *TemplateFormatter) FormatTo(w io.Writer, err error) error {
+	return f.Template.Execute(w, NewTemplateData(err))
+}
+
+// Builds the [TemplateData] of an error chain.
+// A nil error gives an empty TemplateData, so templates don't have to check for it.
+func NewTemplateData(err error) *TemplateData {
+	if err == nil {
+		return &TemplateData{Last: true}
+	}
+	if members := joinMembers(err); len(members) == 1 {
+		err = members[0]
+	}
+	root := newTemplateData(err, 0)
+	last := root
+	for {
+		if last.Cause != nil {
+			last = last.Cause
+		} else if len(last.Causes) > 0 {
+			last = last.Causes[len(last.Causes)-1]
+		} else {
+			break
+		}
+	}
+	last.Last = true
+	return root
+}
+
+func newTemplateData(err error, depth int) *TemplateData {
+	data := &TemplateData{Depth: depth}
+	betterr, ok := err.(*BetterError)
+	if !ok {
+		r := Redact(err)
+		data.Message = r.Msg
+		data.Frames = r.Frames
+		// Only the outermost join is a layer, see formatLayers
+		data.Causes = newTemplateCauses(joinMembers(err), depth+1)
+		return data
+	}
+	r := Redact(betterr)
+	data.Message = r.Msg
+	data.Template = r.Template
+	data.Args = r.Args
+	data.Frames = r.Frames
+	data.Attrs = r.Attrs
+	data.IsBetterError = true
+	data.RemoteService = betterr.remoteService()
+	if betterr.Wrapped == nil {
+		return data
+	}
+	if members := joinMembers(betterr.Wrapped); members != nil {
+		causes := newTemplateCauses(members, depth+1)
+		if len(causes) == 1 {
+			data.Cause = causes[0]
+		} else {
+			data.Causes = causes
+		}
+	} else {
+		data.Cause = newTemplateData(betterr.Wrapped, depth+1)
+	}
+	return data
+}
+
+func newTemplateCauses(errs []error, depth int) []*TemplateData {
+	var causes []*TemplateData
+	for _, err := range errs {
+		causes = append(causes, newTemplateData(err, depth))
+	}
+	return causes
+}
+
+func shortFile(file string) string {
+	return filepath.Base(file)
+}
+
+func shortFunc(function string) string {
+	_, name := SplitFunctionName(function)
+	return name
+}
+
+func indent(spaces int, s string) string {
+	prefix := strings.Repeat(" ", spaces)
+	lines := strings.SplitAfter(s, "\n")
+	sb := strings.Builder{}
+	for _, line := range lines {
+		if line != "" {
+			sb.WriteString(prefix)
+			sb.WriteString(line)
+		}
+	}
+	return sb.String()
+}
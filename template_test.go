@@ -0,0 +1,88 @@
+package betterr
+
+import (
+	"errors"
+	"testing"
+)
+
+func mockedChain() error {
+	return &BetterError{
+		Msg: "process failed",
+		Stack: &mockedStacktrace{
+			frames: []StackFrames{
+				{File: "/src/myapp/file.go", Function: "github.com/myapp.MyFunction", Line: 123},
+				{File: "/src/myapp/main.go", Function: "github.com/myapp.main", Line: 45},
+			},
+		},
+		Attrs: []Attr{{Key: "item", Value: 123}},
+		Wrapped: &BetterError{
+			Msg: "something went wrong",
+			Stack: &mockedStacktrace{
+				frames: []StackFrames{
+					{File: "/src/myapp/file.go", Function: "github.com/myapp.(*Worker).Run", Line: 42},
+				},
+			},
+			Wrapped: errors.New("connection refused"),
+		},
+	}
+}
+
+func TestTemplateFormatter_BundledTemplates(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+	}{
+		{"plain error", errors.New("A plain Go error")},
+		{"mocked chain", mockedChain()},
+		{"runtime stack", Decorate(New("A BetterError error"), "Decorated")},
+		{"remote cause", Decorate(WithAttrs(New("not found"), Attr{Key: RemoteServiceKey, Value: "users"}), "Decorated")},
+		{"joined causes", Errorf("both failed: %w, %w", Decorate(errors.New("first"), "Decorated"), New("second"))},
+		{"joined plain causes", Decorate(errors.Join(errors.New("first"), errors.New("second")), "Decorated")},
+		{"join", errors.Join(New("first"), errors.Join(errors.New("second"), mockedChain()))},
+		{"foreign stack cause", Decorate(newPkgError("connection refused"), "Decorated")},
+		{"foreign stack", &callersError{pcs: callers()}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assertEqual(t, new(GoStyleFormatter).Format(tc.err), MustTemplateFormatter(GoStyleTemplate).Format(tc.err))
+			assertEqual(t, new(JavaStyleFormatter).Format(tc.err), MustTemplateFormatter(JavaStyleTemplate).Format(tc.err))
+		})
+	}
+}
+
+func TestTemplateFormatter_DataModel(t *testing.T) {
+	f := MustTemplateFormatter(
+		`{{define "e"}}{{indent .Depth .Message}}{{range .Attrs}} [{{.Key}}={{.Value}}]{{end}}` +
+			`{{range .Frames}} <- {{shortFunc .Function}}@{{shortFile .File}}{{end}}` + "\n" +
+			`{{with .Cause}}{{template "e" .}}{{end}}{{end}}{{template "e" .}}`)
+
+	assertEqual(t,
+		"process failed [item=123] <- MyFunction@file.go <- main@main.go\n"+
+			" something went wrong <- (*Worker).Run@file.go\n"+
+			"  connection refused\n",
+		f.Format(mockedChain()))
+}
+
+func TestTemplateFormatter_Errors(t *testing.T) {
+	_, err := NewTemplateFormatter("{{.Message")
+	assertTrue(t, err != nil)
+
+	f := MustTemplateFormatter("{{.Message}}{{.Unknown}}")
+	assertRegexp(t, `^something\%!\(TEMPLATE .*Unknown.*\)$`, f.Format(New("something")))
+}
+
+func TestNewTemplateData(t *testing.T) {
+	assertEqual(t, "", NewTemplateData(nil).Message)
+	assertTrue(t, NewTemplateData(nil).Cause == nil)
+
+	data := NewTemplateData(mockedChain())
+	assertEqual(t, "process failed", data.Message)
+	assertEqual(t, 0, data.Depth)
+	assertEqual(t, 2, len(data.Frames))
+	assertTrue(t, data.IsBetterError)
+	assertEqual(t, "connection refused", data.Cause.Cause.Message)
+	assertEqual(t, 2, data.Cause.Cause.Depth)
+	assertFalse(t, data.Cause.Cause.IsBetterError)
+	assertTrue(t, data.Cause.Cause.Cause == nil)
+}